@@ -1,6 +1,6 @@
 module go-nuget-list
 
-go 1.17
+go 1.18
 
 require (
 	github.com/antchfx/xmlquery v1.3.11
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pterm/pterm"
+	"go-nuget-list/pkg/sln"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ignoredDirectories are skipped while searching for orphaned project files.
+var ignoredDirectories = []string{"bin", "obj", "node_modules", "packages"}
+
+type SolutionChecker struct{}
+
+func NewSolutionChecker() *SolutionChecker {
+	return &SolutionChecker{}
+}
+
+func (sc *SolutionChecker) Check(ctx context.Context, fileName string) (*CheckResult, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pterm.Info.Println("Checking solution consistency...")
+
+	sp, err := sln.NewSolutionParser(f).Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CheckResult{Solution: fileName}
+	fileDir := filepath.Dir(fileName)
+
+	referenced := make(map[string]bool)
+	guids := make(map[string][]sln.Project)
+	names := make(map[string][]sln.Project)
+	var ambiguousRoots []string
+
+	for _, p := range sp.Projects {
+		guid := strings.ToUpper(p.ID)
+		guids[guid] = append(guids[guid], p)
+
+		// solution folders with the same name are allowed under different parents
+		kind := p.Type().Kind
+		if kind == sln.KindSolutionFolder {
+			continue
+		}
+
+		names[strings.ToLower(p.Name)] = append(names[strings.ToLower(p.Name)], p)
+
+		if kind == sln.KindWebSite {
+			continue
+		}
+
+		projectPath, err := NewPathResolver().Resolve(fileDir, p.ProjectFile)
+		if err != nil {
+			var ambiguous *AmbiguousPathError
+			if errors.As(err, &ambiguous) {
+				// candidates may be directories, so everything below them counts as referenced
+				ambiguousRoots = append(ambiguousRoots, ambiguous.Candidates...)
+				result.add(IssueAmbiguousProject, p.Name, p.ProjectFile, fmt.Sprintf("project file path matches %d entries",
+					len(ambiguous.Candidates)))
+			} else {
+				result.add(IssueMissingProject, p.Name, p.ProjectFile, "project file does not exist")
+			}
+			continue
+		}
+
+		referenced[filepath.Clean(projectPath)] = true
+	}
+
+	err = filepath.Walk(fileDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != fileDir && isIgnoredDirectory(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !sln.IsManagedProjectFile(path) || referenced[filepath.Clean(path)] {
+			return nil
+		}
+
+		for _, root := range ambiguousRoots {
+			if path == root || strings.HasPrefix(path, root+string(filepath.Separator)) {
+				return nil
+			}
+		}
+
+		rel, err := filepath.Rel(fileDir, path)
+		if err != nil {
+			rel = path
+		}
+
+		result.add(IssueOrphanedProject, strings.TrimSuffix(info.Name(), filepath.Ext(path)), rel,
+			"project file is not included in the solution")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for guid, projects := range guids {
+		if guid == "" || len(projects) < 2 {
+			continue
+		}
+		for _, p := range projects {
+			result.add(IssueDuplicateGUID, p.Name, p.ProjectFile, fmt.Sprintf("project GUID %s is used %d times",
+				p.ID, len(projects)))
+		}
+	}
+
+	for _, projects := range names {
+		if len(projects) < 2 {
+			continue
+		}
+		for _, p := range projects {
+			result.add(IssueDuplicateName, p.Name, p.ProjectFile, fmt.Sprintf("project name is used %d times",
+				len(projects)))
+		}
+	}
+
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		if result.Issues[i].Kind != result.Issues[j].Kind {
+			return result.Issues[i].Kind < result.Issues[j].Kind
+		}
+		return result.Issues[i].Path < result.Issues[j].Path
+	})
+
+	pterm.Info.Println("Found problems:", len(result.Issues))
+	return result, nil
+}
+
+func isIgnoredDirectory(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	for _, d := range ignoredDirectories {
+		if strings.EqualFold(name, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSolutionCheckerDuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+
+	solution := `Microsoft Visual Studio Solution File, Format Version 12.00
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "Tests", "Tests", "{11111111-1111-1111-1111-111111111111}"
+EndProject
+Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "Tests", "Tests", "{22222222-2222-2222-2222-222222222222}"
+EndProject
+Project("{9A19103F-16F7-4668-BE54-9A1E7A4F7556}") = "App", "App\App.csproj", "{33333333-3333-3333-3333-333333333333}"
+EndProject
+Project("{9A19103F-16F7-4668-BE54-9A1E7A4F7556}") = "app", "Legacy\App.csproj", "{44444444-4444-4444-4444-444444444444}"
+EndProject
+`
+	fileName := filepath.Join(dir, "App.sln")
+	if err := os.WriteFile(fileName, []byte(solution), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"App/App.csproj", "Legacy/App.csproj"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := NewSolutionChecker().Check(context.Background(), fileName)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	// only the projects are duplicates, solution folders may share names under different parents
+	var duplicates []string
+	for _, issue := range result.Issues {
+		if issue.Kind != IssueDuplicateName {
+			t.Errorf("unexpected issue %+v", issue)
+			continue
+		}
+		duplicates = append(duplicates, issue.Project)
+	}
+	if len(duplicates) != 2 || duplicates[0] == "Tests" || duplicates[1] == "Tests" {
+		t.Errorf("duplicate names = %v, want App and app", duplicates)
+	}
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pterm/pterm"
+	"go-nuget-list/pkg/nuget"
+	"sync"
+)
+
+// fetcher fills in package metadata using a bounded pool of workers. Every package source
+// has its own semaphore so a single feed never receives more than perSource concurrent requests.
+type fetcher struct {
+	ps       *PackagesScanner
+	nc       *nuget.Client
+	output   *Output
+	progress *pterm.ProgressbarPrinter
+
+	mu     sync.Mutex
+	limits map[string]chan struct{}
+}
+
+// fetch resolves the metadata of all output packages. Packages are updated in place, so the
+// order of output.Packages is the same regardless of the order in which the requests complete.
+// Packages which have not been fetched when the context is done get the context error.
+func (ps *PackagesScanner) fetch(ctx context.Context, nc *nuget.Client, output *Output) {
+	progress, _ := pterm.DefaultProgressbar.WithTotal(len(output.Packages)).WithTitle("Fetching NuGet data..").Start()
+
+	f := &fetcher{
+		ps:       ps,
+		nc:       nc,
+		output:   output,
+		progress: progress,
+		limits:   make(map[string]chan struct{}),
+	}
+
+	jobs := make(chan *OutputPackage)
+
+	var wg sync.WaitGroup
+	for i := 0; i < ps.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				f.fetchPackage(ctx, p)
+			}
+		}()
+	}
+
+	for _, p := range output.Packages {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+
+	progress.RemoveWhenDone = true
+	progress.Stop()
+}
+
+func (f *fetcher) fetchPackage(ctx context.Context, p *OutputPackage) {
+	defer f.increment()
+
+	if err := ctx.Err(); err != nil {
+		p.Error = err.Error()
+		return
+	}
+
+	sources, mapped := f.ps.packageSources(p.Id)
+	if !mapped {
+		pterm.Warning.Println(fmt.Sprintf("NuGet package (%s) has no package source mapping", p.Id))
+
+		f.mu.Lock()
+		f.output.UnmappedPackages = append(f.output.UnmappedPackages, p.Id)
+		f.mu.Unlock()
+	}
+
+	if f.ps.packagesFolder != nil {
+		if d, ok := f.ps.packagesFolder.Find(p.Id, p.Version); ok {
+			p.update(d)
+			pterm.Success.Println(fmt.Sprintf("NuGet package (%s) has been read from the global packages folder", p.Id))
+			return
+		}
+	}
+
+	for _, source := range sources {
+		f.updateTitle(fmt.Sprintf("Fetching NuGet package data (%s) from '%s'...", p.Id, source.SourceName))
+
+		d, err := f.metadata(ctx, source, p)
+		if err != nil {
+			if ctx.Err() != nil {
+				p.Error = ctx.Err().Error()
+				return
+			}
+			if !errors.Is(err, nuget.ErrPackageNotFound) {
+				pterm.Error.Println(fmt.Sprintf("Failed to fetch NuGet package (%s) from '%s'\nError: %s", p.Id,
+					source.SourceName, err))
+				p.Error = fmt.Sprintf("%s: %s", source.SourceName, err)
+			}
+			continue
+		}
+
+		p.update(*d)
+		p.Error = ""
+
+		pterm.Success.Println(fmt.Sprintf("NuGet package (%s) has been successfully fetched", p.Id))
+		return
+	}
+
+	pterm.Warning.Println(fmt.Sprintf("Failed to fetch NuGet package (%s)", p.Id))
+}
+
+// metadata queries a single source while holding one of its request slots.
+func (f *fetcher) metadata(ctx context.Context, source nuget.PackageSource, p *OutputPackage) (*nuget.PackageData, error) {
+	limit := f.limit(source)
+
+	select {
+	case limit <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-limit }()
+
+	return f.nc.Metadata(ctx, source, p.Id, p.Version)
+}
+
+func (f *fetcher) limit(source nuget.PackageSource) chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	limit, ok := f.limits[source.Path]
+	if !ok {
+		limit = make(chan struct{}, f.ps.perSource)
+		f.limits[source.Path] = limit
+	}
+
+	return limit
+}
+
+// The progress bar is not safe for concurrent use.
+
+func (f *fetcher) updateTitle(title string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.progress.UpdateTitle(title)
+}
+
+func (f *fetcher) increment() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.progress.Increment()
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// AmbiguousPathError is returned when a path matches several existing paths case-insensitively.
+type AmbiguousPathError struct {
+	Path       string
+	Candidates []string
+}
+
+func (e *AmbiguousPathError) Error() string {
+	return fmt.Sprintf("ambiguous path %s, candidates: %s", e.Path, strings.Join(e.Candidates, ", "))
+}
+
+// PathResolver resolves paths written on Windows against the local filesystem.
+type PathResolver struct{}
+
+func NewPathResolver() *PathResolver {
+	return &PathResolver{}
+}
+
+// Resolve joins the relative path to the base directory. Mixed separators are normalized and
+// path segments are matched case-insensitively against the directory entries. Every matching entry
+// is followed, so an error is returned only if no or several complete paths exist.
+func (pr *PathResolver) Resolve(baseDir string, relPath string) (string, error) {
+	relPath = filepath.FromSlash(strings.Replace(relPath, `\`, "/", -1))
+
+	resolved := relPath
+	if !filepath.IsAbs(relPath) {
+		resolved = filepath.Join(baseDir, relPath)
+	}
+
+	if _, err := os.Stat(resolved); err == nil {
+		return resolved, nil
+	}
+
+	// ".." segments cannot be followed from a relative directory like "."
+	current, err := filepath.Abs(baseDir)
+	if err != nil {
+		return resolved, err
+	}
+	if filepath.IsAbs(relPath) {
+		current = filepath.VolumeName(relPath) + string(filepath.Separator)
+	}
+
+	found, err := pr.resolveSegments(current, strings.Split(relPath, string(filepath.Separator)))
+	if err != nil {
+		return resolved, err
+	}
+
+	// ".." segments can lead several branches back to the same path
+	var matches []string
+	seen := make(map[string]bool)
+	for _, path := range found {
+		// matches are relative to a relative base directory, like the joined path
+		if !filepath.IsAbs(relPath) && !filepath.IsAbs(baseDir) {
+			if rel, err := filepath.Rel(current, path); err == nil {
+				path = filepath.Join(baseDir, rel)
+			}
+		}
+
+		if !seen[path] {
+			seen[path] = true
+			matches = append(matches, path)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return resolved, &os.PathError{Op: "resolve", Path: resolved, Err: os.ErrNotExist}
+	case 1:
+		return matches[0], nil
+	default:
+		return resolved, &AmbiguousPathError{Path: resolved, Candidates: matches}
+	}
+}
+
+// resolveSegments returns every existing path below the directory which matches the segments case-insensitively.
+func (pr *PathResolver) resolveSegments(dir string, segments []string) ([]string, error) {
+	if len(segments) == 0 {
+		return []string{dir}, nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+	switch segment {
+	case "", ".":
+		return pr.resolveSegments(dir, rest)
+	case "..":
+		return pr.resolveSegments(filepath.Dir(dir), rest)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// a file or a missing entry has no children to match
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var matches []string
+	for _, e := range entries {
+		if !strings.EqualFold(e.Name(), segment) {
+			continue
+		}
+
+		found, err := pr.resolveSegments(filepath.Join(dir, e.Name()), rest)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+
+	return matches, nil
+}
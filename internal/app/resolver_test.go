@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathResolverResolve(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"Src/MyApp/MyApp.csproj",
+		"src/Other/Other.csproj",
+		"Lib/Dup/Dup.csproj",
+		"lib/dup/dup.csproj",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		relPath   string
+		want      string
+		ambiguous []string
+		notExist  bool
+	}{
+		{relPath: `Src\MyApp\MyApp.csproj`, want: "Src/MyApp/MyApp.csproj"},
+		// the exact "src" entry exists but the project is only found below "Src"
+		{relPath: `src\MyApp\MyApp.csproj`, want: "Src/MyApp/MyApp.csproj"},
+		{relPath: `SRC\myapp\myapp.CSPROJ`, want: "Src/MyApp/MyApp.csproj"},
+		{relPath: `src\Other\Other.csproj`, want: "src/Other/Other.csproj"},
+		{relPath: `.\Src\..\src\MyApp\MyApp.csproj`, want: "Src/MyApp/MyApp.csproj"},
+		{relPath: `LIB\DUP\DUP.csproj`, ambiguous: []string{"Lib/Dup/Dup.csproj", "lib/dup/dup.csproj"}},
+		{relPath: `src\Missing\Missing.csproj`, notExist: true},
+		{relPath: `Src\MyApp\MyApp.csproj\Nested.csproj`, notExist: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			got, err := NewPathResolver().Resolve(dir, tt.relPath)
+
+			switch {
+			case tt.notExist:
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("Resolve() error = %v, want not exist", err)
+				}
+			case tt.ambiguous != nil:
+				var ambiguous *AmbiguousPathError
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("Resolve() error = %v, want AmbiguousPathError", err)
+				}
+
+				var want []string
+				for _, c := range tt.ambiguous {
+					want = append(want, filepath.Join(dir, filepath.FromSlash(c)))
+				}
+				if !reflect.DeepEqual(ambiguous.Candidates, want) {
+					t.Errorf("Candidates = %v, want %v", ambiguous.Candidates, want)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Resolve() error = %v", err)
+				}
+				if want := filepath.Join(dir, filepath.FromSlash(tt.want)); got != want {
+					t.Errorf("Resolve() = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPathResolverResolveRelativeBase(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"App/App.sln", "Sibling/Common/Common.csproj"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the solution is passed as typed, so its directory is "."
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "App")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	got, err := NewPathResolver().Resolve(".", `..\sibling\common\Common.csproj`)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := filepath.FromSlash("../Sibling/Common/Common.csproj"); got != want {
+		t.Errorf("Resolve() = %q, want %q", got, want)
+	}
+}
@@ -1,43 +1,104 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/pterm/pterm"
 	"go-nuget-list/pkg/csproj"
 	"go-nuget-list/pkg/nuget"
 	"go-nuget-list/pkg/sln"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 )
 
 type PackagesScanner struct {
-	sources []nuget.PackageSource
+	sources        []nuget.PackageSource
+	mapping        *nuget.PackageSourceMapping
+	packagesFolder *nuget.PackagesFolder
+	client         *nuget.Client
+	workers        int
+	perSource      int
 }
 
+// DefaultConcurrency is the number of packages fetched at the same time unless configured otherwise.
+const DefaultConcurrency = 8
+
+// DefaultSourceConcurrency is the number of concurrent requests sent to a single package source.
+const DefaultSourceConcurrency = 4
+
 func NewPackagesScanner(sources []nuget.PackageSource) *PackagesScanner {
-	return &PackagesScanner{sources: sources}
+	return &PackagesScanner{sources: sources, workers: DefaultConcurrency, perSource: DefaultSourceConcurrency}
 }
 
-func (ps *PackagesScanner) Scan(fileName string) (*Output, error) {
+// WithSourceMapping routes each package lookup to its mapped sources only.
+func (ps *PackagesScanner) WithSourceMapping(mapping *nuget.PackageSourceMapping) *PackagesScanner {
+	ps.mapping = mapping
+	return ps
+}
+
+// WithPackagesFolder reads metadata of restored packages from the global packages folder
+// before querying the package sources.
+func (ps *PackagesScanner) WithPackagesFolder(folder *nuget.PackagesFolder) *PackagesScanner {
+	ps.packagesFolder = folder
+	return ps
+}
+
+// WithClient sets the NuGet client used to query the package sources.
+func (ps *PackagesScanner) WithClient(client *nuget.Client) *PackagesScanner {
+	ps.client = client
+	return ps
+}
+
+// WithConcurrency sets the number of packages fetched at the same time and the number of
+// concurrent requests allowed per package source. Values below one are treated as one.
+func (ps *PackagesScanner) WithConcurrency(workers, perSource int) *PackagesScanner {
+	if workers < 1 {
+		workers = 1
+	}
+	if perSource < 1 {
+		perSource = 1
+	}
+
+	ps.workers = workers
+	ps.perSource = perSource
+	return ps
+}
+
+// Scan reads the packages referenced by the solution or project and fetches their metadata. When the context
+// is canceled or its deadline expires, the results gathered so far are returned together with the context error.
+func (ps *PackagesScanner) Scan(ctx context.Context, fileName string) (*Output, error) {
 	pterm.Info.Println("Starting packages scanner...")
 
 	output := &Output{}
 
 	if strings.HasSuffix(fileName, ".sln") {
 		pterm.Info.Println("Solution file detected...")
-		err := ps.scanSolution(output, fileName)
+		err := ps.scanSolution(ctx, output, fileName)
 		if err != nil {
+			if ctx.Err() != nil {
+				return output, err
+			}
 			return nil, err
 		}
 	} else {
 		pterm.Info.Println("Project file detected...")
-		err := ps.scanProject(output, fileName)
+		err := ps.scanProject(ctx, output, fileName)
 		if err != nil {
+			if ctx.Err() != nil {
+				return output, err
+			}
 			return nil, err
 		}
+
+		output.Projects = append(output.Projects, &OutputProject{
+			Name:    strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)),
+			Path:    fileName,
+			Type:    "MSBuild",
+			Scanned: true,
+		})
 	}
 
 	pterm.Info.Println("Total packages:", output.TotalPackages)
@@ -46,62 +107,81 @@ func (ps *PackagesScanner) Scan(fileName string) (*Output, error) {
 		return output.Packages[i].Id+output.Packages[i].Version < output.Packages[j].Id+output.Packages[j].Version
 	})
 
-	progress, _ := pterm.DefaultProgressbar.WithTotal(len(output.Packages)).WithTitle("Fetching NuGet data..").Start()
-	nc := nuget.NewNugetClient()
-
-	for _, p := range output.Packages {
-		var found = false
+	nc := ps.client
+	if nc == nil {
+		nc = nuget.NewNugetClient()
+	}
+	ps.prefetch(ctx, nc, output.Packages)
 
-		for _, source := range ps.sources {
-			progress.UpdateTitle(fmt.Sprintf("Fetching NuGet package data (%s) from '%s'...", p.Id,
-				source.SourceName))
+	ps.fetch(ctx, nc, output)
 
-			response, err := nc.Search(source.Path, p.Id)
-			if err != nil {
-				pterm.Error.Println(fmt.Sprintf("Failed to fetch NuGet package (%s) from '%s'\nError: %s", p.Id,
-					source.SourceName, err))
-			}
+	sort.Strings(output.UnmappedPackages)
 
-			for _, d := range response.Data {
-				// check package version?
-				if d.Id != p.Id /*|| d.Version != p.Version*/ {
-					continue
-				}
+	if err := ctx.Err(); err != nil {
+		pterm.Warning.Println("Packages scanning has been interrupted:", err)
+		return output, err
+	}
 
-				p.Name = d.Title
-				p.Description = d.Description
-				p.Summary = d.Summary
-				p.Authors = d.Authors.Values
-				p.Tags = d.Tags.Values
-				p.LicenseUrl = d.LicenseUrl
-				p.ProjectUrl = d.ProjectUrl
+	pterm.Info.Println("Packages scanning has been completed")
+	return output, nil
+}
 
-				found = true
+// prefetch queries the V2 package sources in batches for all packages routed to them,
+// which are not available in the global packages folder.
+func (ps *PackagesScanner) prefetch(ctx context.Context, nc *nuget.Client, packages []*OutputPackage) {
+	batches := make(map[string][]nuget.PackageIdentity)
 
-				pterm.Success.Println(fmt.Sprintf("NuGet package (%s) has been successfully fetched", p.Id))
-				break
+	for _, p := range packages {
+		if ps.packagesFolder != nil {
+			if _, ok := ps.packagesFolder.Find(p.Id, p.Version); ok {
+				continue
 			}
+		}
 
-			if found {
-				break
-			}
+		sources, _ := ps.packageSources(p.Id)
+		for _, source := range sources {
+			batches[source.Path] = append(batches[source.Path], nuget.PackageIdentity{Id: p.Id, Version: p.Version})
 		}
+	}
 
-		progress.Increment()
+	for _, source := range ps.sources {
+		if len(batches[source.Path]) == 0 {
+			continue
+		}
 
-		if !found {
-			pterm.Warning.Println(fmt.Sprintf("Failed to fetch NuGet package (%s)", p.Id))
+		if err := nc.PrefetchV2(ctx, source, batches[source.Path]); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			pterm.Warning.Println(fmt.Sprintf("Failed to prefetch NuGet packages from '%s'\nWarning: %s",
+				source.SourceName, err))
 		}
 	}
+}
+
+// packageSources returns the sources to query for the package id. When package source mapping
+// is enabled only the mapped sources are returned, and false is reported if there are none.
+func (ps *PackagesScanner) packageSources(id string) ([]nuget.PackageSource, bool) {
+	if !ps.mapping.IsEnabled() {
+		return ps.sources, true
+	}
 
-	progress.RemoveWhenDone = true
-	progress.Stop()
+	var sources []nuget.PackageSource
 
-	pterm.Info.Println("Packages scanning has been completed")
-	return output, nil
+	names := ps.mapping.SourcesFor(id)
+	for _, source := range ps.sources {
+		for _, name := range names {
+			if strings.EqualFold(source.SourceName, name) {
+				sources = append(sources, source)
+				break
+			}
+		}
+	}
+
+	return sources, len(names) > 0
 }
 
-func (ps *PackagesScanner) scanSolution(data *Output, fileName string) error {
+func (ps *PackagesScanner) scanSolution(ctx context.Context, data *Output, fileName string) error {
 	f, err := os.Open(fileName)
 	if err != nil {
 		return err
@@ -118,16 +198,70 @@ func (ps *PackagesScanner) scanSolution(data *Output, fileName string) error {
 	}
 	spinner.Success()
 
+	data.Solution = &OutputSolution{
+		FileName:                   fileName,
+		FormatVersion:              sp.FormatVersion,
+		Product:                    sp.Product,
+		VisualStudioVersion:        sp.VisualStudioVersion,
+		MinimumVisualStudioVersion: sp.MinimumVisualStudioVersion,
+	}
+
+	if err := sp.CheckFormat(); err != nil {
+		pterm.Warning.Println(fmt.Sprintf("Solution file may not be parsed correctly\nWarning: %s", err))
+	} else {
+		data.Solution.FormatSupported = true
+	}
+
+	pterm.Info.Println("Solution format version:", sp.FormatVersion)
+
 	spinner, _ = pterm.DefaultSpinner.Start("Parsing project files...")
 
 	for _, p := range sp.Projects {
-		if !strings.HasSuffix(p.ProjectFile, ".csproj") {
+		if err := ctx.Err(); err != nil {
+			spinner.Fail()
+			return err
+		}
+
+		pt := p.Type()
+
+		if pt.Kind == sln.KindSolutionFolder {
 			continue
 		}
 
-		if err := ps.scanProject(data, path.Join(fileDir, p.ProjectFile)); err != nil {
+		op := &OutputProject{
+			Name: p.Name,
+			Path: p.ProjectFile,
+			Type: pt.Name,
+		}
+		data.Projects = append(data.Projects, op)
+
+		if !p.IsManaged() {
+			pterm.Info.Println(fmt.Sprintf("Skipping %s project (%s)", pt.Name, p.Name))
+			continue
+		}
+
+		projectPath, err := NewPathResolver().Resolve(fileDir, p.ProjectFile)
+		if err != nil {
+			var ambiguous *AmbiguousPathError
+			if errors.As(err, &ambiguous) {
+				pterm.Warning.Println(fmt.Sprintf("Project file (%s) matches several files:\n%s", p.ProjectFile,
+					strings.Join(ambiguous.Candidates, "\n")))
+			} else {
+				pterm.Error.Println(fmt.Sprintf("Failed to find project file (%s)\nError: %s", p.ProjectFile, err))
+			}
+			continue
+		}
+
+		if projectPath != filepath.Join(fileDir, p.ProjectFile) {
+			op.Path, _ = filepath.Rel(fileDir, projectPath)
+		}
+
+		if err := ps.scanProject(ctx, data, projectPath); err != nil {
 			pterm.Error.Println(fmt.Sprintf("Failed to parse project file (%s)\nError: %s", p.ProjectFile, err))
+			continue
 		}
+
+		op.Scanned = true
 	}
 
 	spinner.Success()
@@ -135,7 +269,11 @@ func (ps *PackagesScanner) scanSolution(data *Output, fileName string) error {
 	return nil
 }
 
-func (ps *PackagesScanner) scanProject(data *Output, fileName string) error {
+func (ps *PackagesScanner) scanProject(ctx context.Context, data *Output, fileName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	pr := csproj.NewProjectParser()
 	prj, err := pr.Parse(fileName)
 	if err != nil {
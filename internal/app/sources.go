@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/pterm/pterm"
+	"go-nuget-list/pkg/nuget"
+)
+
+type SourcesChecker struct {
+	client *nuget.Client
+}
+
+func NewSourcesChecker() *SourcesChecker {
+	return &SourcesChecker{client: nuget.NewNugetClient()}
+}
+
+// WithClient sets the NuGet client used to probe the package sources.
+func (sc *SourcesChecker) WithClient(client *nuget.Client) *SourcesChecker {
+	sc.client = client
+	return sc
+}
+
+// Check probes every enabled package source. Disabled sources are listed without being contacted.
+func (sc *SourcesChecker) Check(ctx context.Context, sources []nuget.PackageSource) *SourcesResult {
+	result := &SourcesResult{}
+
+	spinner, _ := pterm.DefaultSpinner.Start("Checking package sources...")
+
+	for _, source := range sources {
+		sr := &SourceResult{
+			Name:       source.SourceName,
+			Location:   source.Path,
+			ConfigFile: source.FileName,
+			Enabled:    !source.Disabled,
+		}
+		result.Sources = append(result.Sources, sr)
+
+		if source.Disabled {
+			continue
+		}
+
+		spinner.UpdateText(fmt.Sprintf("Checking package source '%s'...", source.SourceName))
+
+		status := sc.client.Probe(ctx, source)
+		sr.Protocol = status.Protocol
+		sr.Reachable = status.Reachable
+		sr.LatencyMs = status.Latency.Milliseconds()
+
+		if status.Error != nil {
+			sr.Error = status.Error.Error()
+		}
+	}
+
+	spinner.Success()
+	return result
+}
@@ -4,25 +4,84 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/pterm/pterm"
+	"go-nuget-list/pkg/nuget"
 	"io/ioutil"
 )
 
 type Output struct {
-	ScannedProjects int32            `json:"scannedProjects"`
-	TotalPackages   int32            `json:"usedPackages"`
-	Packages        []*OutputPackage `json:"packages"`
+	Solution         *OutputSolution  `json:"solution,omitempty"`
+	ScannedProjects  int32            `json:"scannedProjects"`
+	TotalPackages    int32            `json:"usedPackages"`
+	Projects         []*OutputProject `json:"projects,omitempty"`
+	Packages         []*OutputPackage `json:"packages"`
+	UnmappedPackages []string         `json:"unmappedPackages,omitempty"`
+}
+
+type OutputProject struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Type    string `json:"type"`
+	Scanned bool   `json:"scanned"`
+}
+
+type OutputSolution struct {
+	FileName                   string `json:"fileName"`
+	FormatVersion              string `json:"formatVersion"`
+	FormatSupported            bool   `json:"formatSupported"`
+	Product                    string `json:"product,omitempty"`
+	VisualStudioVersion        string `json:"visualStudioVersion,omitempty"`
+	MinimumVisualStudioVersion string `json:"minimumVisualStudioVersion,omitempty"`
 }
 
 type OutputPackage struct {
-	Id          string   `json:"id"`
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Summary     string   `json:"summary,omitempty"`
-	Version     string   `json:"version"`
-	Authors     []string `json:"authors,omitempty"`
-	Tags        []string `json:"tags,omitempty"`
-	LicenseUrl  string   `json:"licenseUrl"`
-	ProjectUrl  string   `json:"projectUrl"`
+	Id           string                   `json:"id"`
+	Name         string                   `json:"name"`
+	Description  string                   `json:"description"`
+	Summary      string                   `json:"summary,omitempty"`
+	Version      string                   `json:"version"`
+	Authors      []string                 `json:"authors,omitempty"`
+	Tags         []string                 `json:"tags,omitempty"`
+	LicenseUrl   string                   `json:"licenseUrl"`
+	License      string                   `json:"license,omitempty"`
+	ProjectUrl   string                   `json:"projectUrl"`
+	Repository   string                   `json:"repository,omitempty"`
+	Copyright    string                   `json:"copyright,omitempty"`
+	ReleaseNotes string                   `json:"releaseNotes,omitempty"`
+	Dependencies []*OutputDependencyGroup `json:"dependencies,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+}
+
+type OutputDependencyGroup struct {
+	TargetFramework string              `json:"targetFramework,omitempty"`
+	Dependencies    []*OutputDependency `json:"dependencies"`
+}
+
+type OutputDependency struct {
+	Id      string `json:"id"`
+	Version string `json:"version,omitempty"`
+}
+
+func (op *OutputPackage) update(d nuget.PackageData) {
+	op.Name = d.Title
+	op.Description = d.Description
+	op.Summary = d.Summary
+	op.Authors = d.Authors.Values
+	op.Tags = d.Tags.Values
+	op.LicenseUrl = d.LicenseUrl
+	op.License = d.LicenseExpression
+	op.ProjectUrl = d.ProjectUrl
+	op.Repository = d.RepositoryUrl
+	op.Copyright = d.Copyright
+	op.ReleaseNotes = d.ReleaseNotes
+	op.Dependencies = nil
+
+	for _, g := range d.DependencyGroups {
+		og := &OutputDependencyGroup{TargetFramework: g.TargetFramework}
+		for _, dep := range g.Dependencies {
+			og.Dependencies = append(og.Dependencies, &OutputDependency{Id: dep.Id, Version: dep.Range})
+		}
+		op.Dependencies = append(op.Dependencies, og)
+	}
 }
 
 func (o *Output) Print() {
@@ -53,3 +112,106 @@ func (o *Output) SaveToFile(fileName string) error {
 	}
 	return ioutil.WriteFile(fileName, outputFile, 0644)
 }
+
+const (
+	IssueMissingProject   = "missing"
+	IssueAmbiguousProject = "ambiguous"
+	IssueOrphanedProject  = "orphaned"
+	IssueDuplicateGUID    = "duplicate-guid"
+	IssueDuplicateName    = "duplicate-name"
+)
+
+type CheckResult struct {
+	Solution string        `json:"solution"`
+	Issues   []*CheckIssue `json:"issues"`
+}
+
+type CheckIssue struct {
+	Kind    string `json:"kind"`
+	Project string `json:"project"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (cr *CheckResult) add(kind, project, path, message string) {
+	cr.Issues = append(cr.Issues, &CheckIssue{
+		Kind:    kind,
+		Project: project,
+		Path:    path,
+		Message: message,
+	})
+}
+
+func (cr *CheckResult) Print() {
+	fmt.Println()
+
+	td := pterm.TableData{
+		{"Problem", "Project", "Path", "Details"},
+	}
+
+	for _, i := range cr.Issues {
+		td = append(td, []string{i.Kind, i.Project, i.Path, i.Message})
+	}
+
+	pterm.DefaultTable.WithHasHeader().WithData(td).Render()
+	fmt.Println()
+}
+
+func (cr *CheckResult) SaveToFile(fileName string) error {
+	outputFile, err := json.MarshalIndent(cr, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, outputFile, 0644)
+}
+
+type SourcesResult struct {
+	Sources []*SourceResult `json:"sources"`
+}
+
+type SourceResult struct {
+	Name       string `json:"name"`
+	Location   string `json:"location"`
+	ConfigFile string `json:"configFile"`
+	Enabled    bool   `json:"enabled"`
+	Protocol   string `json:"protocol,omitempty"`
+	Reachable  bool   `json:"reachable"`
+	LatencyMs  int64  `json:"latencyMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (sr *SourcesResult) Print() {
+	fmt.Println()
+
+	td := pterm.TableData{
+		{"Name", "Location", "Config file", "Status", "Protocol", "Latency", "Error"},
+	}
+
+	for _, s := range sr.Sources {
+		status := "disabled"
+		latency := ""
+
+		if s.Enabled {
+			status = "unreachable"
+			if s.Reachable {
+				status = "ok"
+			}
+			latency = fmt.Sprintf("%d ms", s.LatencyMs)
+		}
+
+		td = append(td, []string{s.Name, s.Location, s.ConfigFile, status, s.Protocol, latency, s.Error})
+	}
+
+	pterm.DefaultTable.WithHasHeader().WithData(td).Render()
+	fmt.Println()
+}
+
+func (sr *SourcesResult) PrintJSON() error {
+	output, err := json.MarshalIndent(sr, "", " ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/pterm/pterm"
 	"github.com/urfave/cli/v2"
 	"go-nuget-list/internal/app"
 	"go-nuget-list/pkg/nuget"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -23,16 +28,39 @@ func main() {
 
 			pterm.Info.Println("Input file:", fileName)
 
-			packageSources, err := nuget.NewNugetConfigFinder().Search(fileName)
+			settings, err := searchSettings(c, fileName)
 			if err != nil {
 				return err
 			}
 
-			result, err := app.NewPackagesScanner(packageSources).Scan(fileName)
+			// the sources command still lists configurations where every source is disabled
+			if len(settings.EnabledPackageSources()) < 1 {
+				return errors.New("no package sources found")
+			}
+
+			ctx := c.Context
+			if timeout := c.Duration("timeout"); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			client, err := newClient(c, settings)
 			if err != nil {
 				return err
 			}
 
+			result, scanErr := app.NewPackagesScanner(settings.EnabledPackageSources()).
+				WithSourceMapping(settings.PackageSourceMapping()).
+				WithPackagesFolder(nuget.NewPackagesFolder(settings.GlobalPackagesFolder())).
+				WithClient(client).
+				WithConcurrency(c.Int("concurrency"), c.Int("source-concurrency")).
+				Scan(ctx, fileName)
+			if result == nil {
+				return scanErr
+			}
+
+			// partial results of an interrupted scan are written as well
 			outputFile := c.String("output")
 
 			if outputFile != "" {
@@ -46,19 +74,324 @@ func main() {
 
 			}
 
+			if scanErr != nil {
+				return scanErr
+			}
+
 			pterm.Info.Println("DONE!")
 			return nil
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "output",
 				Usage:   "output file",
 				Aliases: []string{"o"},
 			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "number of packages fetched at the same time",
+				Value: app.DefaultConcurrency,
+			},
+			&cli.IntFlag{
+				Name:  "source-concurrency",
+				Usage: "maximum number of concurrent requests sent to a single package source",
+				Value: app.DefaultSourceConcurrency,
+			},
+			&cli.IntFlag{
+				Name:  "retries",
+				Usage: "number of retries of requests failing with a transient error",
+				Value: nuget.DefaultRetryPolicy.MaxRetries,
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "deadline of the whole scan, e.g. 5m, zero for no deadline",
+			},
+			&cli.BoolFlag{
+				Name:  "no-cache",
+				Usage: "do not read or write the HTTP cache",
+			},
+		}, append(sourceFlags(), transportFlags()...)...),
+		Commands: []*cli.Command{
+			{
+				Name:      "check",
+				Usage:     "report missing, orphaned and duplicate projects of a solution",
+				ArgsUsage: "<solution.sln>",
+				Action: func(c *cli.Context) error {
+					fileName := c.Args().Get(0)
+
+					if !strings.HasSuffix(fileName, ".sln") {
+						return errors.New("unknown input file format")
+					}
+
+					pterm.Info.Println("Input file:", fileName)
+
+					result, err := app.NewSolutionChecker().Check(c.Context, fileName)
+					if err != nil {
+						return err
+					}
+
+					outputFile := c.String("output")
+
+					if outputFile != "" {
+						if err = result.SaveToFile(outputFile); err != nil {
+							return err
+						}
+						pterm.Info.Println("Results successfully saved saved to:", outputFile)
+					} else if len(result.Issues) > 0 {
+						result.Print()
+					}
+
+					if len(result.Issues) > 0 {
+						return fmt.Errorf("solution has %d problems", len(result.Issues))
+					}
+
+					pterm.Success.Println("No problems found")
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   "output file",
+						Aliases: []string{"o"},
+					},
+				},
+			},
+			{
+				Name:      "sources",
+				Usage:     "list the effective package sources and check their health",
+				ArgsUsage: "[path]",
+				Action: func(c *cli.Context) error {
+					path := c.Args().Get(0)
+					if path == "" {
+						path = "."
+					}
+
+					asJSON := c.Bool("json")
+					if asJSON {
+						pterm.DisableOutput()
+						defer pterm.EnableOutput()
+					}
+
+					settings, err := searchSettings(c, path)
+					if err != nil {
+						return err
+					}
+
+					opts, err := transportOptions(c, settings)
+					if err != nil {
+						return err
+					}
+
+					client, err := nuget.NewNugetClientWithTransport(opts)
+					if err != nil {
+						return err
+					}
+
+					result := app.NewSourcesChecker().WithClient(client).Check(c.Context, settings.PackageSources())
+
+					if asJSON {
+						return result.PrintJSON()
+					}
+
+					result.Print()
+					return nil
+				},
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the result as JSON",
+					},
+				}, append(sourceFlags(), transportFlags()...)...),
+			},
+			{
+				Name:  "cache",
+				Usage: "manage the HTTP cache of package metadata",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "clear",
+						Usage: "remove all cached responses",
+						Action: func(c *cli.Context) error {
+							dir, err := nuget.DefaultCacheDir()
+							if err != nil {
+								return err
+							}
+
+							if err = nuget.NewHTTPCache(dir).Clear(); err != nil {
+								return err
+							}
+
+							pterm.Success.Println("HTTP cache has been cleared:", dir)
+							return nil
+						},
+					},
+				},
+			},
 		},
 	}
 
-	if err := cli.Run(os.Args); err != nil {
+	// the first interrupt cancels the running command, the second one terminates the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := cli.RunContext(ctx, os.Args); err != nil {
 		pterm.Error.Println(err)
+		stop()
+		// a failed command, like check finding problems, has to fail a CI build
+		os.Exit(1)
+	}
+}
+
+// sourceFlags returns the flags which override the package sources found in NuGet configuration.
+func sourceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "configfile",
+			Usage: "NuGet configuration file to use instead of the configuration hierarchy",
+		},
+		&cli.StringSliceFlag{
+			Name:    "source",
+			Usage:   "package source to query before the configured sources, can be repeated",
+			Aliases: []string{"s"},
+		},
+		&cli.BoolFlag{
+			Name:  "no-default-sources",
+			Usage: "ignore package sources declared in NuGet configuration files",
+		},
 	}
 }
+
+// searchSettings loads the NuGet configuration for the path with the command line overrides applied.
+func searchSettings(c *cli.Context, path string) (*nuget.Settings, error) {
+	return nuget.NewNugetConfigFinder().
+		WithConfigFile(c.String("configfile")).
+		WithSources(c.StringSlice("source")...).
+		WithNoDefaultSources(c.Bool("no-default-sources")).
+		Search(path)
+}
+
+// transportFlags returns the flags which configure the HTTP connections to package sources.
+func transportFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "proxy",
+			Usage: "HTTP proxy URL, overrides http_proxy of NuGet configuration and the environment",
+		},
+		&cli.StringFlag{
+			Name:  "proxy-user",
+			Usage: "user name for the HTTP proxy",
+		},
+		&cli.StringFlag{
+			Name:    "proxy-password",
+			Usage:   "password for the HTTP proxy",
+			EnvVars: []string{"GO_NUGET_LIST_PROXY_PASSWORD"},
+		},
+		&cli.StringFlag{
+			Name:  "no-proxy",
+			Usage: "comma separated hosts which are not connected through the proxy",
+		},
+		&cli.StringFlag{
+			Name:  "ca-bundle",
+			Usage: "PEM file with additional trusted CA certificates, overrides ca_bundle of NuGet configuration",
+		},
+		&cli.DurationFlag{
+			Name:  "http-timeout",
+			Usage: "timeout of a single request to a package source, overrides http_timeout of NuGet configuration",
+			Value: nuget.DefaultTimeout,
+		},
+		&cli.StringSliceFlag{
+			Name:  "source-timeout",
+			Usage: "request timeout of a package source in the form name=duration, can be repeated, overrides http_timeout.<name> of NuGet configuration",
+		},
+		&cli.StringFlag{
+			Name:  "user-agent",
+			Usage: "User-Agent header sent to package sources, overrides user_agent of NuGet configuration",
+			Value: nuget.DefaultUserAgent,
+		},
+	}
+}
+
+// transportOptions returns the transport options of NuGet configuration with the command line overrides applied.
+func transportOptions(c *cli.Context, settings *nuget.Settings) (nuget.TransportOptions, error) {
+	opts, err := settings.TransportOptions()
+	if err != nil {
+		return opts, err
+	}
+
+	if c.IsSet("proxy") {
+		opts.Proxy = c.String("proxy")
+		opts.ProxyUser = ""
+		opts.ProxyPassword = ""
+	}
+	if c.IsSet("proxy-user") {
+		opts.ProxyUser = c.String("proxy-user")
+	}
+	if c.IsSet("proxy-password") {
+		opts.ProxyPassword = c.String("proxy-password")
+	}
+	if c.IsSet("no-proxy") {
+		opts.NoProxy = c.String("no-proxy")
+	}
+
+	if c.IsSet("ca-bundle") {
+		opts.CABundle = c.String("ca-bundle")
+	}
+	if c.IsSet("http-timeout") {
+		opts.Timeout = c.Duration("http-timeout")
+	}
+	if c.IsSet("user-agent") {
+		opts.UserAgent = c.String("user-agent")
+	}
+
+	for _, value := range c.StringSlice("source-timeout") {
+		name, timeout, err := nuget.ParseSourceTimeout(value)
+		if err != nil {
+			return opts, err
+		}
+
+		if opts.SourceTimeouts == nil {
+			opts.SourceTimeouts = make(map[string]time.Duration)
+		}
+		// source names are case-insensitive, the flag replaces a timeout of NuGet configuration
+		for configured := range opts.SourceTimeouts {
+			if strings.EqualFold(configured, name) {
+				delete(opts.SourceTimeouts, configured)
+			}
+		}
+		opts.SourceTimeouts[name] = timeout
+	}
+
+	return opts, nil
+}
+
+// newClient creates the NuGet client configured by NuGet configuration and the command line flags.
+func newClient(c *cli.Context, settings *nuget.Settings) (*nuget.Client, error) {
+	opts, err := transportOptions(c, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := nuget.NewNugetClientWithTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := nuget.DefaultRetryPolicy
+	policy.MaxRetries = c.Int("retries")
+	client.WithRetryPolicy(policy)
+
+	if !c.Bool("no-cache") {
+		dir, err := nuget.DefaultCacheDir()
+		if err != nil {
+			pterm.Warning.Println("HTTP cache is disabled:", err)
+			return client, nil
+		}
+		client.WithCache(nuget.NewHTTPCache(dir))
+	}
+
+	return client, nil
+}
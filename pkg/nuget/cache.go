@@ -0,0 +1,353 @@
+package nuget
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMaxSize is the size the HTTP cache is pruned to when it grows larger.
+const DefaultCacheMaxSize = 256 << 20
+
+// HTTPCache stores responses of package sources on disk. Fresh entries are used without contacting the
+// source, stale entries are revalidated with If-None-Match and If-Modified-Since.
+type HTTPCache struct {
+	Dir string
+	// MaxSize is the total size of the cached responses in bytes, zero disables the limit.
+	MaxSize int64
+
+	// ServiceIndexTTL, RegistrationTTL and NuspecTTL set how long the responses are used without revalidation.
+	ServiceIndexTTL time.Duration
+	RegistrationTTL time.Duration
+	NuspecTTL       time.Duration
+
+	mu   sync.Mutex
+	size int64
+	// sized is set once the size of the existing entries has been read.
+	sized bool
+}
+
+// cacheResource is the kind of resource a cached response belongs to, which selects its TTL.
+type cacheResource int
+
+const (
+	cacheServiceIndex cacheResource = iota
+	cacheRegistration
+	cacheNuspec
+)
+
+// cacheEntry is the metadata stored next to the cached response body.
+type cacheEntry struct {
+	Url          string    `json:"url"`
+	ContentType  string    `json:"contentType,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Stored       time.Time `json:"stored"`
+}
+
+// NewHTTPCache returns a cache in the directory with the default TTLs and size limit. Package
+// manifests of a version never change, so they are kept much longer than the other resources.
+func NewHTTPCache(dir string) *HTTPCache {
+	return &HTTPCache{
+		Dir:             dir,
+		MaxSize:         DefaultCacheMaxSize,
+		ServiceIndexTTL: time.Hour,
+		RegistrationTTL: 30 * time.Minute,
+		NuspecTTL:       7 * 24 * time.Hour,
+	}
+}
+
+// DefaultCacheDir returns the HTTP cache directory under the user cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "go-nuget-list", "http"), nil
+}
+
+// Clear removes all cached responses.
+func (hc *HTTPCache) Clear() error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.size = 0
+	hc.sized = true
+	return os.RemoveAll(hc.Dir)
+}
+
+func (hc *HTTPCache) ttl(resource cacheResource) time.Duration {
+	switch resource {
+	case cacheServiceIndex:
+		return hc.ServiceIndexTTL
+	case cacheRegistration:
+		return hc.RegistrationTTL
+	case cacheNuspec:
+		return hc.NuspecTTL
+	}
+	return 0
+}
+
+func (hc *HTTPCache) path(requestUrl string) string {
+	sum := sha256.Sum256([]byte(requestUrl))
+	return filepath.Join(hc.Dir, hex.EncodeToString(sum[:]))
+}
+
+// load returns the cached entry and body of the URL, or false if the URL is not cached.
+func (hc *HTTPCache) load(requestUrl string) (*cacheEntry, []byte, bool) {
+	p := hc.path(requestUrl)
+
+	meta, err := ioutil.ReadFile(p + ".json")
+	if err != nil {
+		return nil, nil, false
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(meta, entry); err != nil || entry.Url != requestUrl {
+		return nil, nil, false
+	}
+
+	body, err := ioutil.ReadFile(p + ".body")
+	if err != nil {
+		return nil, nil, false
+	}
+
+	// the modification time of the body orders the entries for pruning
+	now := time.Now()
+	_ = os.Chtimes(p+".body", now, now)
+
+	return entry, body, true
+}
+
+// store saves the response body. The files are written to temporary files first, so concurrent
+// readers never see a partial entry.
+func (hc *HTTPCache) store(entry *cacheEntry, body []byte) error {
+	if err := os.MkdirAll(hc.Dir, 0755); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	p := hc.path(entry.Url)
+	// an overwritten entry only changes the cache size by the difference
+	previous := fileSize(p+".body") + fileSize(p+".json")
+
+	if err := writeFileAtomic(p+".body", body); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p+".json", meta); err != nil {
+		return err
+	}
+
+	hc.grow(int64(len(body)+len(meta)) - previous)
+	return nil
+}
+
+// touch marks the entry as revalidated.
+func (hc *HTTPCache) touch(entry *cacheEntry) error {
+	entry.Stored = time.Now()
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	p := hc.path(entry.Url) + ".json"
+	previous := fileSize(p)
+
+	if err := writeFileAtomic(p, meta); err != nil {
+		return err
+	}
+
+	hc.grow(int64(len(meta)) - previous)
+	return nil
+}
+
+// grow adds the difference of the stored bytes to the cache size and prunes the cache once it exceeds MaxSize.
+func (hc *HTTPCache) grow(n int64) {
+	if hc.MaxSize <= 0 {
+		return
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if !hc.sized {
+		hc.size, _ = hc.usage()
+		hc.sized = true
+	} else {
+		hc.size += n
+	}
+
+	if hc.size > hc.MaxSize {
+		hc.size, _ = hc.prune(hc.MaxSize * 9 / 10)
+	}
+}
+
+// usage returns the total size of the files in the cache directory.
+func (hc *HTTPCache) usage() (int64, error) {
+	files, err := ioutil.ReadDir(hc.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, f := range files {
+		size += f.Size()
+	}
+	return size, nil
+}
+
+// prune removes the least recently used entries until the cache is not larger than the limit.
+func (hc *HTTPCache) prune(limit int64) (int64, error) {
+	files, err := ioutil.ReadDir(hc.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	sizes := make(map[string]int64)
+	var bodies []os.FileInfo
+
+	for _, f := range files {
+		size += f.Size()
+
+		key := strings.TrimSuffix(strings.TrimSuffix(f.Name(), ".json"), ".body")
+		sizes[key] += f.Size()
+
+		if strings.HasSuffix(f.Name(), ".body") {
+			bodies = append(bodies, f)
+		}
+	}
+
+	sort.Slice(bodies, func(i, j int) bool {
+		return bodies[i].ModTime().Before(bodies[j].ModTime())
+	})
+
+	for _, f := range bodies {
+		if size <= limit {
+			break
+		}
+
+		key := strings.TrimSuffix(f.Name(), ".body")
+		os.Remove(filepath.Join(hc.Dir, key+".json"))
+		os.Remove(filepath.Join(hc.Dir, key+".body"))
+		size -= sizes[key]
+	}
+
+	return size, nil
+}
+
+// fileSize returns the size of the file, or zero if it does not exist.
+func fileSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func writeFileAtomic(name string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
+
+// getCached returns the response from the HTTP cache while it is younger than the TTL of the resource and
+// revalidates it with the source afterwards. Without a cache the request is sent directly.
+func (c *Client) getCached(ctx context.Context, source PackageSource, requestUrl string, resource cacheResource) (*http.Response, error) {
+	hc := c.cache
+	if hc == nil || hc.ttl(resource) <= 0 {
+		return c.get(ctx, source, requestUrl)
+	}
+
+	entry, body, ok := hc.load(requestUrl)
+	if ok && time.Since(entry.Stored) < hc.ttl(resource) {
+		return cachedResponse(entry, body), nil
+	}
+
+	header := http.Header{}
+	if ok && entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+	if ok && entry.LastModified != "" {
+		header.Set("If-Modified-Since", entry.LastModified)
+	}
+
+	r, err := c.getWithHeader(ctx, source, requestUrl, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.StatusCode == http.StatusNotModified {
+		r.Body.Close()
+		if !ok {
+			return nil, fmt.Errorf("unexpected response from %s: %s", requestUrl, r.Status)
+		}
+
+		_ = hc.touch(entry)
+		return cachedResponse(entry, body), nil
+	}
+
+	body, err = ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entry = &cacheEntry{
+		Url:          requestUrl,
+		ContentType:  r.Header.Get("Content-Type"),
+		ETag:         r.Header.Get("ETag"),
+		LastModified: r.Header.Get("Last-Modified"),
+		Stored:       time.Now(),
+	}
+	// a failing cache must not fail the request
+	_ = hc.store(entry, body)
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return r, nil
+}
+
+func cachedResponse(entry *cacheEntry, body []byte) *http.Response {
+	header := http.Header{}
+	if entry.ContentType != "" {
+		header.Set("Content-Type", entry.ContentType)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
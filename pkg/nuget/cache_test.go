@@ -0,0 +1,181 @@
+package nuget
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCachedFeed starts a source which serves a new body with a new ETag for every version and answers
+// conditional requests for the current version with 304 Not Modified.
+func newCachedFeed(t *testing.T, version *int32) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		v := atomic.LoadInt32(version)
+		etag := fmt.Sprintf(`"v%d"`, v)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"version": "%d", "padding": "%s"}`, v, strings.Repeat("x", int(v)*10))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func readCached(t *testing.T, client *Client, requestUrl string) string {
+	t.Helper()
+
+	r, err := client.getCached(context.Background(), PackageSource{SourceName: "feed", Path: requestUrl},
+		requestUrl, cacheServiceIndex)
+	if err != nil {
+		t.Fatalf("getCached() error = %v", err)
+	}
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestHTTPCacheTTLAndRevalidation(t *testing.T) {
+	version := int32(1)
+	server, requests := newCachedFeed(t, &version)
+
+	cache := NewHTTPCache(t.TempDir())
+	client := NewNugetClient().WithRetryPolicy(RetryPolicy{}).WithCache(cache)
+	requestUrl := server.URL + "/index.json"
+
+	first := readCached(t, client, requestUrl)
+	if second := readCached(t, client, requestUrl); second != first {
+		t.Errorf("cached body = %q, want %q", second, first)
+	}
+	if n := atomic.LoadInt32(requests); n != 1 {
+		t.Fatalf("requests = %d, want 1 while the entry is fresh", n)
+	}
+
+	// a stale entry is revalidated and reused after 304 Not Modified
+	cache.ServiceIndexTTL = time.Nanosecond
+
+	if body := readCached(t, client, requestUrl); body != first {
+		t.Errorf("revalidated body = %q, want %q", body, first)
+	}
+	if n := atomic.LoadInt32(requests); n != 2 {
+		t.Fatalf("requests = %d, want 2 after revalidation", n)
+	}
+
+	// a changed response replaces the entry
+	atomic.StoreInt32(&version, 2)
+
+	if body := readCached(t, client, requestUrl); !strings.Contains(body, `"version": "2"`) {
+		t.Errorf("body = %q, want version 2", body)
+	}
+
+	entry, _, ok := cache.load(requestUrl)
+	if !ok || entry.ETag != `"v2"` {
+		t.Errorf("cached entry = %+v, want ETag \"v2\"", entry)
+	}
+}
+
+func TestHTTPCacheSizeOfOverwrittenEntries(t *testing.T) {
+	version := int32(1)
+	server, _ := newCachedFeed(t, &version)
+
+	cache := NewHTTPCache(t.TempDir())
+	cache.ServiceIndexTTL = time.Nanosecond
+	client := NewNugetClient().WithRetryPolicy(RetryPolicy{}).WithCache(cache)
+
+	for v := int32(1); v <= 10; v++ {
+		atomic.StoreInt32(&version, v)
+		readCached(t, client, server.URL+"/index.json")
+		readCached(t, client, server.URL+"/other.json")
+	}
+
+	usage, err := cache.usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.size != usage {
+		t.Errorf("size = %d, want the disk usage %d", cache.size, usage)
+	}
+}
+
+func TestHTTPCachePrune(t *testing.T) {
+	cache := NewHTTPCache(t.TempDir())
+	body := []byte(strings.Repeat("x", 1000))
+
+	store := func(name string) {
+		t.Helper()
+		if err := cache.store(&cacheEntry{Url: "https://feed.example/" + name, Stored: time.Now()}, body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the entries are used from the oldest to the newest
+	for i, name := range []string{"a", "b", "c"} {
+		store(name)
+
+		used := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(cache.path("https://feed.example/"+name)+".body", used, used); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := cache.usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the fourth entry exceeds the limit, pruning to 90% of it removes the least recently used entry
+	cache.MaxSize = usage + usage/4
+	store("d")
+
+	for name, want := range map[string]bool{"a": false, "b": true, "c": true, "d": true} {
+		if _, _, ok := cache.load("https://feed.example/" + name); ok != want {
+			t.Errorf("entry %s cached = %v, want %v", name, ok, want)
+		}
+	}
+
+	if usage, _ := cache.usage(); cache.size != usage || usage > cache.MaxSize {
+		t.Errorf("size = %d, usage = %d, want equal and at most %d", cache.size, usage, cache.MaxSize)
+	}
+}
+
+func TestHTTPCacheClear(t *testing.T) {
+	cache := NewHTTPCache(t.TempDir())
+
+	if err := cache.store(&cacheEntry{Url: "https://feed.example/a", Stored: time.Now()}, []byte("body")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	if _, _, ok := cache.load("https://feed.example/a"); ok {
+		t.Error("entry is still cached after Clear()")
+	}
+	if _, err := os.Stat(cache.Dir); !os.IsNotExist(err) {
+		t.Errorf("cache directory still exists: %v", err)
+	}
+	if cache.size != 0 {
+		t.Errorf("size = %d, want 0", cache.size)
+	}
+}
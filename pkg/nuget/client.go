@@ -1,90 +1,296 @@
 package nuget
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"mime"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
+	"strings"
+	"sync"
 	"time"
 )
 
+// errNotFoundStatus is wrapped into errors of requests which returned 404 Not Found.
+var errNotFoundStatus = errors.New("not found")
+
+// maxV2Pages limits the number of pages read from a V2 feed for a single query.
+const maxV2Pages = 100
+
+// semVerLevel makes package sources include packages with SemVer 2.0.0 versions in query results.
+const semVerLevel = "2.0.0"
+
 type Client struct {
-	endpoints []string
-	client    *http.Client
+	client     *http.Client
+	mu         sync.Mutex
+	indexes    map[string]*serviceIndexEntry
+	prefetched map[string]*v2Prefetch
+	retry      RetryPolicy
+	cache      *HTTPCache
+	transport  TransportOptions
 }
 
-// NewNugetClient returns a new instance of Parser.
+// NewNugetClient returns a new instance of Client with the default transport options.
 func NewNugetClient() *Client {
-	return &Client{client: &http.Client{Timeout: 10 * time.Second}}
+	c, _ := NewNugetClientWithTransport(DefaultTransportOptions())
+	return c
 }
 
-func (c *Client) Search(sourceUrl string, id string) (*ResponseQuery, error) {
-	r, err := c.client.Get(sourceUrl)
+// NewNugetClientWithTransport returns a new instance of Client which connects to the package sources
+// through the configured proxy, trusts the CA bundle and applies the timeouts and User-Agent.
+func NewNugetClientWithTransport(opts TransportOptions) (*Client, error) {
+	transport, err := newTransport(opts)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
 
-	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return &Client{
+		client:     &http.Client{Transport: transport},
+		indexes:    make(map[string]*serviceIndexEntry),
+		prefetched: make(map[string]*v2Prefetch),
+		retry:      DefaultRetryPolicy,
+		transport:  opts,
+	}, nil
+}
+
+// WithCache stores service indexes, registration pages and package manifests in the HTTP cache.
+func (c *Client) WithCache(cache *HTTPCache) *Client {
+	c.cache = cache
+	return c
+}
+
+// WithRetryPolicy sets how requests failing with a transient error are retried.
+func (c *Client) WithRetryPolicy(policy RetryPolicy) *Client {
+	c.retry = policy
+	return c
+}
+
+func (c *Client) Search(ctx context.Context, source PackageSource, id string) (*ResponseQuery, error) {
+	if source.IsLocal() {
+		return NewLocalFeed(source.LocalPath()).Search(ctx, id)
+	}
+
+	index, err := c.ServiceIndex(ctx, source)
 	if err != nil {
 		return nil, err
 	}
 
-	if mediaType == "application/xml" {
-		return c.QueryApiV2(sourceUrl, id)
+	if index.Protocol == ProtocolV2 {
+		return c.QueryApiV2(ctx, source, source.Path, id)
+	}
+
+	if index.SearchQueryService == "" {
+		return nil, errors.New("search query service not found")
 	}
 
-	if mediaType == "application/json" {
-		response := &ResponseResources{}
-		err = json.NewDecoder(r.Body).Decode(&response)
+	return c.QueryApiV3(ctx, source, index.SearchQueryService, id)
+}
+
+// Metadata returns the metadata of the package version. Exact versions are looked up in the V3 registration
+// resource, V2 feeds and local feeds; ranges and floating versions, as well as V3 feeds without a registration
+// resource, fall back to the search results.
+func (c *Client) Metadata(ctx context.Context, source PackageSource, id string, version string) (*PackageData, error) {
+	exact := IsExactVersion(version)
+
+	if !source.IsLocal() && exact {
+		index, err := c.ServiceIndex(ctx, source)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, resource := range response.Resources {
-			if resource.Type == "SearchQueryService" {
-				return c.QueryApiV3(resource.Id, id)
+		if index.Protocol == ProtocolV2 {
+			if d := c.prefetchedV2(source, id, version); d != nil {
+				return d, nil
 			}
+			return c.QueryApiV2Version(ctx, source, source.Path, id, version)
 		}
 
-		return nil, errors.New("search query service not found")
+		if index.RegistrationsBaseUrl != "" || index.PackageBaseAddress != "" {
+			return c.metadataV3(ctx, source, index, id, version)
+		}
+	}
+
+	response, err := c.Search(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// without an exact version the highest version found is used
+	var data *PackageData
+
+	for i, d := range response.Data {
+		if !strings.EqualFold(d.Id, id) {
+			continue
+		}
+
+		if exact && !SameVersion(d.Version, version) {
+			continue
+		}
+
+		if data == nil || CompareVersions(d.Version, data.Version) > 0 {
+			data = &response.Data[i]
+		}
 	}
 
-	return nil, errors.New(fmt.Sprintf("unknown media type: %s", mediaType))
+	if data == nil {
+		return nil, ErrPackageNotFound
+	}
+	return data, nil
 }
 
-func (c *Client) QueryApiV2(sourceUrl string, id string) (*ResponseQuery, error) {
+// metadataV3 reads the registration catalog entry and complements it with the package manifest
+// from the flat container, which also contains dependencies, repository and release notes.
+func (c *Client) metadataV3(ctx context.Context, source PackageSource, index *ServiceIndex, id string, version string) (*PackageData, error) {
+	var data *PackageData
+
+	if index.RegistrationsBaseUrl != "" {
+		d, err := c.Registration(ctx, source, id, version)
+		if err != nil {
+			return nil, err
+		}
+		data = d
+	}
+
+	if index.PackageBaseAddress == "" {
+		return data, nil
+	}
+
+	nuspec, err := c.FlatContainerNuspec(ctx, source, id, version)
+	if err != nil {
+		// the registration entry is still usable without the manifest
+		if data != nil {
+			return data, nil
+		}
+		return nil, err
+	}
+
+	if data == nil {
+		d := nuspec.PackageData()
+		return &d, nil
+	}
+
+	data.merge(nuspec.PackageData())
+	return data, nil
+}
+
+// QueryApiV2 returns every version of the package, following the paging links of the V2 feed.
+func (c *Client) QueryApiV2(ctx context.Context, source PackageSource, sourceUrl string, id string) (*ResponseQuery, error) {
 	u, err := url.Parse(sourceUrl)
 	if err != nil {
 		return nil, err
 	}
 
 	q := u.Query()
-	q.Set("id", "'"+id+"'")
+	q.Set("id", "'"+escapeODataString(id)+"'")
+	q.Set("semVerLevel", semVerLevel)
 
 	u.RawQuery = q.Encode()
-	u.Path = path.Join(u.Path, "FindPackagesById()")
+	setODataPath(u, "FindPackagesById()")
 
-	r, err := c.client.Get(u.String())
+	return c.queryApiV2Pages(ctx, source, u.String())
+}
+
+// QueryApiV2Version returns the exact package version using the Packages(Id,Version) entity lookup.
+// The version is tried as written in the project and in its normalized form.
+func (c *Client) QueryApiV2Version(ctx context.Context, source PackageSource, sourceUrl string, id string, version string) (*PackageData, error) {
+	u, err := url.Parse(sourceUrl)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
 
-	response := &ResponseQuery{}
-	err = xml.NewDecoder(r.Body).Decode(&response)
-	if err != nil {
-		return nil, err
+	versions := []string{version}
+	if normalized := NormalizeVersion(version); normalized != version {
+		versions = append(versions, normalized)
 	}
 
-	return response, nil
+	base := *u
+
+	for _, v := range versions {
+		u := base
+		setODataPath(&u, fmt.Sprintf("Packages(Id='%s',Version='%s')", escapeODataString(id),
+			escapeODataString(v)))
+
+		r, err := c.get(ctx, source, u.String())
+		if err != nil {
+			if errors.Is(err, errNotFoundStatus) {
+				continue
+			}
+			return nil, err
+		}
+
+		data := &PackageData{}
+		err = xml.NewDecoder(r.Body).Decode(data)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		data.normalizeVersion()
+		return data, nil
+	}
+
+	return nil, ErrPackageNotFound
 }
 
-func (c *Client) QueryApiV3(sourceUrl string, id string) (*ResponseQuery, error) {
+// queryApiV2Pages reads a V2 feed and all following pages linked with rel="next".
+func (c *Client) queryApiV2Pages(ctx context.Context, source PackageSource, requestUrl string) (*ResponseQuery, error) {
+	result := &ResponseQuery{}
+	visited := make(map[string]bool)
+
+	for requestUrl != "" && !visited[requestUrl] && len(visited) < maxV2Pages {
+		visited[requestUrl] = true
+
+		r, err := c.get(ctx, source, requestUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &ResponseQuery{}
+		err = xml.NewDecoder(r.Body).Decode(&response)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range response.Data {
+			response.Data[i].normalizeVersion()
+		}
+		result.Data = append(result.Data, response.Data...)
+
+		requestUrl = response.NextLink()
+	}
+
+	return result, nil
+}
+
+// setODataPath appends the OData resource path to the URL. Parentheses and quotes are sent unescaped,
+// like NuGet does, because some V2 servers do not decode them.
+func setODataPath(u *url.URL, resource string) {
+	escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + escapeODataPath(resource)
+
+	u.Path = path.Join(u.Path, resource)
+	u.RawPath = escaped
+}
+
+// escapeODataPath percent-encodes the resource path, keeping the characters OData uses for keys.
+func escapeODataPath(resource string) string {
+	escaped := url.PathEscape(resource)
+	for _, ch := range []string{"(", ")", "'", ",", "="} {
+		escaped = strings.Replace(escaped, url.PathEscape(ch), ch, -1)
+	}
+	return escaped
+}
+
+// escapeODataString escapes a value for use inside a quoted OData string literal.
+func escapeODataString(value string) string {
+	return strings.Replace(value, "'", "''", -1)
+}
+
+func (c *Client) QueryApiV3(ctx context.Context, source PackageSource, sourceUrl string, id string) (*ResponseQuery, error) {
 	u, err := url.Parse(sourceUrl)
 	if err != nil {
 		return nil, err
@@ -93,10 +299,10 @@ func (c *Client) QueryApiV3(sourceUrl string, id string) (*ResponseQuery, error)
 	q := u.Query()
 	q.Set("q", id)
 	q.Set("prerelease", "false")
-	q.Set("semVerLevel", "2.0.0")
+	q.Set("semVerLevel", semVerLevel)
 	u.RawQuery = q.Encode()
 
-	r, err := c.client.Get(u.String())
+	r, err := c.get(ctx, source, u.String())
 	if err != nil {
 		return nil, err
 	}
@@ -110,3 +316,123 @@ func (c *Client) QueryApiV3(sourceUrl string, id string) (*ResponseQuery, error)
 
 	return response, nil
 }
+
+// Probe checks whether the source is reachable and detects its protocol version.
+func (c *Client) Probe(ctx context.Context, source PackageSource) *SourceStatus {
+	status := &SourceStatus{}
+	started := time.Now()
+
+	if source.IsLocal() {
+		status.Protocol = ProtocolLocal
+
+		info, err := os.Stat(source.LocalPath())
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", source.LocalPath())
+		}
+
+		status.Latency = time.Since(started)
+		status.Error = err
+		status.Reachable = err == nil
+		return status
+	}
+
+	// the latency of a single request is measured, without retry backoff and the HTTP cache
+	probe := &Client{client: c.client, transport: c.transport}
+
+	index, err := probe.fetchServiceIndex(ctx, source)
+	status.Latency = time.Since(started)
+	if err != nil {
+		status.Error = err
+		return status
+	}
+
+	status.Reachable = true
+	status.Protocol = index.Protocol
+	return status
+}
+
+// get sends a GET request with the source credentials and fails on non-successful status codes.
+// Timeouts, connection errors and 429, 502, 503 and 504 responses are retried according to the retry policy.
+func (c *Client) get(ctx context.Context, source PackageSource, requestUrl string) (*http.Response, error) {
+	return c.getWithHeader(ctx, source, requestUrl, nil)
+}
+
+// getWithHeader sends the GET request with additional headers. A 304 Not Modified response to
+// a conditional request is returned to the caller.
+func (c *Client) getWithHeader(ctx context.Context, source PackageSource, requestUrl string, header http.Header) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		r, err := c.send(ctx, source, requestUrl, header)
+		if err != nil {
+			if retry >= c.retry.MaxRetries || ctx.Err() != nil || !retryableError(err) {
+				return nil, err
+			}
+			if err := sleep(ctx, c.retry.delay(retry)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if r.StatusCode >= 200 && r.StatusCode <= 299 || r.StatusCode == http.StatusNotModified && len(header) > 0 {
+			return r, nil
+		}
+
+		r.Body.Close()
+
+		if retryableStatus(r.StatusCode) && retry < c.retry.MaxRetries {
+			delay, ok := c.retry.retryAfter(r)
+			if !ok {
+				delay = c.retry.delay(retry)
+			}
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if r.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s: %w", requestUrl, errNotFoundStatus)
+		}
+		if r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("access denied to %s (%s), check packageSourceCredentials", requestUrl, r.Status)
+		}
+		if retry > 0 {
+			return nil, fmt.Errorf("unexpected response from %s after %d retries: %s", requestUrl, retry, r.Status)
+		}
+		return nil, fmt.Errorf("unexpected response from %s: %s", requestUrl, r.Status)
+	}
+}
+
+// send sends a single request. The timeout of the source covers reading the response body as well.
+func (c *Client) send(ctx context.Context, source PackageSource, requestUrl string, header http.Header) (*http.Response, error) {
+	cancel := func() {}
+	if timeout := c.transport.timeout(source); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if c.transport.UserAgent != "" {
+		req.Header.Set("User-Agent", c.transport.UserAgent)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	if source.Credentials != nil {
+		source.Credentials.apply(req)
+	}
+
+	r, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r.Body = &cancelBody{ReadCloser: r.Body, cancel: cancel}
+	return r, nil
+}
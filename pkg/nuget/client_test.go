@@ -0,0 +1,186 @@
+package nuget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAuthFeed starts a V3 feed which serves its service index only to requests accepted by authorized.
+func newAuthFeed(t *testing.T, authorized func(r *http.Request) bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version": "3.0.0", "resources": []}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func basicAuth(user string, password string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		return ok && u == user && p == password
+	}
+}
+
+func serviceIndex(t *testing.T, source PackageSource) (*ServiceIndex, error) {
+	t.Helper()
+
+	return NewNugetClient().WithRetryPolicy(RetryPolicy{}).ServiceIndex(context.Background(), source)
+}
+
+func TestClientBasicAuth(t *testing.T) {
+	server := newAuthFeed(t, basicAuth("user", "secret"))
+
+	index, err := serviceIndex(t, PackageSource{
+		SourceName:  "feed",
+		Path:        server.URL + "/index.json",
+		Credentials: &PackageSourceCredentials{Username: "user", Password: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("ServiceIndex() error = %v", err)
+	}
+	if index.Protocol != ProtocolV3 {
+		t.Errorf("Protocol = %q, want %q", index.Protocol, ProtocolV3)
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	server := newAuthFeed(t, basicAuth("user", "secret"))
+
+	tests := []struct {
+		name        string
+		credentials *PackageSourceCredentials
+	}{
+		{name: "no credentials"},
+		{name: "wrong password", credentials: &PackageSourceCredentials{Username: "user", Password: "wrong"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := serviceIndex(t, PackageSource{
+				SourceName:  "feed",
+				Path:        server.URL + "/index.json",
+				Credentials: tt.credentials,
+			})
+			if err == nil || !strings.Contains(err.Error(), "check packageSourceCredentials") {
+				t.Fatalf("ServiceIndex() error = %v, want access denied", err)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsFromConfigWithEnvironment(t *testing.T) {
+	server := newAuthFeed(t, basicAuth("ci-user", "from-env"))
+
+	t.Setenv("TEST_FEED_USER", "ci-user")
+	t.Setenv("TEST_FEED_PASSWORD", "from-env")
+
+	config := filepath.Join(t.TempDir(), "NuGet.Config")
+	err := os.WriteFile(config, []byte(`<?xml version="1.0" encoding="utf-8"?>
+<configuration>
+  <packageSources>
+    <add key="Private Feed" value="`+server.URL+`/index.json" />
+  </packageSources>
+  <packageSourceCredentials>
+    <Private_x0020_Feed>
+      <add key="Username" value="%TEST_FEED_USER%" />
+      <add key="ClearTextPassword" value="%TEST_FEED_PASSWORD%" />
+    </Private_x0020_Feed>
+  </packageSourceCredentials>
+</configuration>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ReadConfigFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := (&Settings{Files: []*ConfigFile{file}}).EnabledPackageSources()
+	if len(sources) != 1 {
+		t.Fatalf("EnabledPackageSources() = %d sources, want 1", len(sources))
+	}
+
+	if _, err := serviceIndex(t, sources[0]); err != nil {
+		t.Fatalf("ServiceIndex() error = %v", err)
+	}
+}
+
+func TestClientApiKey(t *testing.T) {
+	server := newAuthFeed(t, func(r *http.Request) bool {
+		_, _, basic := r.BasicAuth()
+		return !basic && r.Header.Get(ApiKeyHeader) == "api-key"
+	})
+
+	_, err := serviceIndex(t, PackageSource{
+		SourceName:  "feed",
+		Path:        server.URL + "/index.json",
+		Credentials: &PackageSourceCredentials{Password: "api-key"},
+	})
+	if err != nil {
+		t.Fatalf("ServiceIndex() error = %v", err)
+	}
+}
+
+func TestClientDoesNotSendPasswordWithoutBasicAuth(t *testing.T) {
+	var header http.Header
+	server := newAuthFeed(t, func(r *http.Request) bool {
+		header = r.Header.Clone()
+		return true
+	})
+
+	_, err := serviceIndex(t, PackageSource{
+		SourceName: "feed",
+		Path:       server.URL + "/index.json",
+		Credentials: &PackageSourceCredentials{
+			Username:                 "user",
+			Password:                 "secret",
+			ValidAuthenticationTypes: []string{"negotiate"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ServiceIndex() error = %v", err)
+	}
+
+	if v := header.Get("Authorization"); v != "" {
+		t.Errorf("Authorization = %q, want none", v)
+	}
+	if v := header.Get(ApiKeyHeader); v != "" {
+		t.Errorf("%s = %q, want none", ApiKeyHeader, v)
+	}
+}
+
+func TestClientProbeDoesNotRetry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewNugetClient().WithRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Second})
+
+	status := client.Probe(context.Background(), PackageSource{SourceName: "feed", Path: server.URL + "/index.json"})
+	if status.Reachable || status.Error == nil {
+		t.Errorf("Probe() = %+v, want unreachable", status)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
@@ -1,57 +1,74 @@
 package nuget
 
 import (
-	"errors"
 	"fmt"
-	"github.com/antchfx/xmlquery"
 	"github.com/pterm/pterm"
-	"log"
-	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
-	"runtime"
-	"strings"
 )
 
-var WindowsLocations = []string{
-	"%ProgramFiles(x86)%\\NuGet\\Config",
-	"%AppData%\\NuGet",
+// ConfigFileNames are the file names NuGet looks for in each directory.
+var ConfigFileNames = []string{
+	"nuget.config",
+	"NuGet.config",
+	"NuGet.Config",
 }
 
-var LinuxLocations = []string{
-	"~/.nuget/NuGet",
-	"~/.config/NuGet",
+type ConfigFinder struct {
+	locations        *ConfigLocations
+	configFile       string
+	sources          []string
+	noDefaultSources bool
 }
 
-type ConfigFinder struct{}
-
-// NewNugetConfigFinder returns a new instance of Parser.
+// NewNugetConfigFinder returns a new instance of ConfigFinder.
 func NewNugetConfigFinder() *ConfigFinder {
-	return &ConfigFinder{}
+	return &ConfigFinder{locations: NewConfigLocations()}
 }
 
-func (cf *ConfigFinder) Search(paths ...string) ([]PackageSource, error) {
-	pterm.Info.Println("Searching NuGet package sources...")
+// WithLocations overrides the user and machine-wide configuration locations.
+func (cf *ConfigFinder) WithLocations(locations *ConfigLocations) *ConfigFinder {
+	cf.locations = locations
+	return cf
+}
 
-	var locations []string
+// WithConfigFile uses the configuration file instead of the directory and user configuration files.
+// Machine-wide configuration is still applied, like with dotnet restore --configfile.
+func (cf *ConfigFinder) WithConfigFile(fileName string) *ConfigFinder {
+	cf.configFile = fileName
+	return cf
+}
 
-	if runtime.GOOS == "windows" {
-		re := regexp.MustCompile(`\%([^\%\%]*)\%`)
+// WithSources adds package sources which are queried before the configured sources.
+func (cf *ConfigFinder) WithSources(sources ...string) *ConfigFinder {
+	cf.sources = append(cf.sources, sources...)
+	return cf
+}
 
-		for _, location := range WindowsLocations {
-			subMatchAll := re.FindAllString(location, -1)
+// WithNoDefaultSources ignores package sources declared in configuration files.
+func (cf *ConfigFinder) WithNoDefaultSources(noDefaultSources bool) *ConfigFinder {
+	cf.noDefaultSources = noDefaultSources
+	return cf
+}
 
-			for _, value := range subMatchAll {
-				environment := os.Getenv(strings.Trim(value, "%"))
-				locations = append(locations, strings.Replace(location, value, environment, -1))
-			}
+// Search loads the NuGet configuration in the order restore uses: config files from the
+// directory of each path up to the root, then the user configuration and machine-wide configuration.
+func (cf *ConfigFinder) Search(paths ...string) (*Settings, error) {
+	pterm.Info.Println("Searching NuGet package sources...")
+
+	// configuration files are collected from the highest to the lowest priority
+	var configs []*ConfigFile
+
+	if cf.configFile != "" {
+		file, err := ReadConfigFile(cf.configFile)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		locations = LinuxLocations
+
+		configs = append(configs, file)
+		paths = nil
 	}
 
-	// add custom nuget paths
 	for _, path := range paths {
 		dir, err := os.Stat(path)
 		if err != nil {
@@ -62,77 +79,97 @@ func (cf *ConfigFinder) Search(paths ...string) ([]PackageSource, error) {
 			path = filepath.Dir(path)
 		}
 
-		locations = append(locations, path)
-	}
-
-	var packageSources []PackageSource
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
 
-	// search all configuration files
-	for _, location := range locations {
-		err := filepath.Walk(location, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+		for {
+			if fileName := findConfigFile(path); fileName != "" {
+				configs = appendConfig(configs, fileName)
 			}
 
-			if info.IsDir() {
-				return nil
+			parent := filepath.Dir(path)
+			if parent == path {
+				break
 			}
+			path = parent
+		}
+	}
 
-			if strings.HasSuffix(strings.ToLower(filepath.Base(path)), ".config") {
-				reader, err := os.Open(path)
-				if err != nil {
-					return err
-				}
-				defer reader.Close()
-
-				doc, err := xmlquery.Parse(reader)
-				if err != nil {
-					return err
-				}
-
-				result, err := xmlquery.QueryAll(doc, "//configuration/packageSources/add")
-
-				for _, source := range result {
-					val := source.SelectAttr("value")
-
-					// TODO: rewrite me :(
-					// skip system paths for this moment
-					_, err := os.Stat(val)
-					if err == nil {
-						continue
-					}
-
-					// only valid HTTP path is allowed
-					if _, err := url.ParseRequestURI(val); err != nil {
-						continue
-					}
-
-					packageSources = append(packageSources, PackageSource{
-						FileName:        path,
-						SourceName:      source.SelectAttr("key"),
-						Path:            val,
-						ProtocolVersion: source.SelectAttr("protocolVersion"),
-					})
-				}
-			}
+	// NuGet creates a default user configuration when it does not exist
+	if cf.configFile == "" {
+		userConfig := cf.locations.UserConfigFile()
+		if fileExists(userConfig) {
+			configs = appendConfig(configs, userConfig)
+		} else {
+			configs = append(configs, newDefaultConfigFile(userConfig))
+		}
 
-			return nil
-		})
-		if err != nil {
-			log.Println(err)
+		for _, fileName := range cf.locations.AdditionalUserConfigFiles() {
+			configs = appendConfig(configs, fileName)
 		}
 	}
 
-	if len(packageSources) < 1 {
-		return nil, errors.New("no package sources found")
+	for _, fileName := range cf.locations.MachineConfigFiles() {
+		configs = appendConfig(configs, fileName)
+	}
+
+	// lower priority files are applied first
+	settings := &Settings{NoDefaultSources: cf.noDefaultSources}
+
+	for _, source := range cf.sources {
+		if (PackageSource{Path: source}).IsLocal() {
+			if abs, err := filepath.Abs(source); err == nil {
+				source = abs
+			}
+		}
+		settings.Sources = append(settings.Sources, source)
 	}
 
+	for i := len(configs) - 1; i >= 0; i-- {
+		settings.Files = append(settings.Files, configs[i])
+	}
+
+	packageSources := settings.PackageSources()
+
 	var displayPaths string
 	for _, ps := range packageSources {
-		displayPaths += fmt.Sprintf("> %s - %s\n", ps.SourceName, ps.FileName)
+		if ps.Disabled {
+			displayPaths += fmt.Sprintf("> %s - %s (disabled)\n", ps.SourceName, ps.FileName)
+		} else {
+			displayPaths += fmt.Sprintf("> %s - %s\n", ps.SourceName, ps.FileName)
+		}
 	}
 
 	pterm.Info.Printf("Found %d package sources:\n%s", len(packageSources), displayPaths)
 
-	return packageSources, nil
+	return settings, nil
+}
+
+// findConfigFile returns the NuGet configuration file in the directory, if any.
+func findConfigFile(dir string) string {
+	for _, name := range ConfigFileNames {
+		if fileName := filepath.Join(dir, name); fileExists(fileName) {
+			return fileName
+		}
+	}
+	return ""
+}
+
+// appendConfig reads the configuration file and appends it, unless it was already read or is invalid.
+func appendConfig(configs []*ConfigFile, fileName string) []*ConfigFile {
+	for _, c := range configs {
+		if c.Path == fileName {
+			return configs
+		}
+	}
+
+	file, err := ReadConfigFile(fileName)
+	if err != nil {
+		pterm.Warning.Println(fmt.Sprintf("Failed to read NuGet configuration (%s)\nWarning: %s", fileName, err))
+		return configs
+	}
+
+	return append(configs, file)
 }
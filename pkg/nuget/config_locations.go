@@ -0,0 +1,95 @@
+package nuget
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// ConfigLocations resolves the user and machine-wide NuGet configuration files of a platform.
+// NUGET_COMMON_APPLICATION_DATA is the only NuGet environment variable which moves configuration
+// files; NUGET_PACKAGES is applied by GlobalPackagesFolder. NUGET_HTTP_CACHE_PATH,
+// NUGET_FALLBACK_PACKAGES and NUGET_PLUGIN_PATHS are deliberately not supported, since the
+// HTTP cache, fallback folders and credential plugins of NuGet are not used.
+type ConfigLocations struct {
+	GOOS   string
+	Home   string
+	Getenv func(key string) string
+}
+
+// NewConfigLocations returns the configuration locations of the current user and platform.
+func NewConfigLocations() *ConfigLocations {
+	home, _ := os.UserHomeDir()
+	return &ConfigLocations{GOOS: runtime.GOOS, Home: home, Getenv: os.Getenv}
+}
+
+// UserConfigDir returns the directory of the user NuGet configuration:
+// %AppData%\NuGet on Windows and ~/.nuget/NuGet elsewhere.
+func (cl *ConfigLocations) UserConfigDir() string {
+	if cl.GOOS == "windows" {
+		return filepath.Join(cl.Getenv("APPDATA"), "NuGet")
+	}
+	return filepath.Join(cl.Home, ".nuget", "NuGet")
+}
+
+// UserConfigFile returns the user NuGet.Config. On Linux and macOS, the legacy location under
+// XDG_CONFIG_HOME (~/.config by default) is used when the NuGet.Config in ~/.nuget/NuGet does not exist.
+func (cl *ConfigLocations) UserConfigFile() string {
+	fileName := filepath.Join(cl.UserConfigDir(), "NuGet.Config")
+	if cl.GOOS == "windows" || fileExists(fileName) {
+		return fileName
+	}
+
+	configHome := cl.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(cl.Home, ".config")
+	}
+
+	if legacy := findConfigFile(filepath.Join(configHome, "NuGet")); legacy != "" {
+		return legacy
+	}
+
+	return fileName
+}
+
+// AdditionalUserConfigFiles returns the *.config files in the config directory next to the user NuGet.Config.
+// They have a lower priority than the user NuGet.Config.
+func (cl *ConfigLocations) AdditionalUserConfigFiles() []string {
+	return globConfigFiles(filepath.Join(cl.UserConfigDir(), "config"))
+}
+
+// MachineConfigDir returns the directory of the machine-wide NuGet configuration. On Linux and macOS
+// the NUGET_COMMON_APPLICATION_DATA environment variable overrides the common application data folder.
+func (cl *ConfigLocations) MachineConfigDir() string {
+	if cl.GOOS == "windows" {
+		return filepath.Join(cl.Getenv("ProgramFiles(x86)"), "NuGet", "Config")
+	}
+
+	common := cl.Getenv("NUGET_COMMON_APPLICATION_DATA")
+	if common == "" {
+		if cl.GOOS == "darwin" {
+			common = filepath.Join("/Library", "Application Support")
+		} else {
+			common = filepath.Join("/etc", "opt")
+		}
+	}
+
+	return filepath.Join(common, "NuGet", "Config")
+}
+
+// MachineConfigFiles returns the machine-wide configuration files.
+func (cl *ConfigLocations) MachineConfigFiles() []string {
+	return globConfigFiles(cl.MachineConfigDir())
+}
+
+func globConfigFiles(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.config"))
+	sort.Strings(matches)
+	return matches
+}
+
+func fileExists(fileName string) bool {
+	info, err := os.Stat(fileName)
+	return err == nil && !info.IsDir()
+}
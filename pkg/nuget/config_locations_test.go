@@ -0,0 +1,217 @@
+package nuget
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newFakeLocations returns Linux configuration locations with a temporary home directory.
+func newFakeLocations(t *testing.T, env map[string]string) *ConfigLocations {
+	t.Helper()
+
+	return &ConfigLocations{
+		GOOS:   "linux",
+		Home:   t.TempDir(),
+		Getenv: func(key string) string { return env[key] },
+	}
+}
+
+func writeConfig(t *testing.T, fileName string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigLocationsUserConfigFile(t *testing.T) {
+	xdgHome := t.TempDir()
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		files []string
+		want  string
+	}{
+		{
+			name: "missing",
+			want: "~/.nuget/NuGet/NuGet.Config",
+		},
+		{
+			name:  "nuget directory",
+			files: []string{"~/.nuget/NuGet/NuGet.Config", "~/.config/NuGet/NuGet.Config"},
+			want:  "~/.nuget/NuGet/NuGet.Config",
+		},
+		{
+			name:  "default XDG_CONFIG_HOME",
+			files: []string{"~/.config/NuGet/NuGet.Config"},
+			want:  "~/.config/NuGet/NuGet.Config",
+		},
+		{
+			name:  "XDG_CONFIG_HOME",
+			env:   map[string]string{"XDG_CONFIG_HOME": xdgHome},
+			files: []string{"~/.config/NuGet/NuGet.Config", xdgHome + "/NuGet/nuget.config"},
+			want:  xdgHome + "/NuGet/nuget.config",
+		},
+		{
+			name:  "XDG_CONFIG_HOME without config",
+			env:   map[string]string{"XDG_CONFIG_HOME": filepath.Join(xdgHome, "empty")},
+			files: []string{"~/.config/NuGet/NuGet.Config"},
+			want:  "~/.nuget/NuGet/NuGet.Config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := newFakeLocations(t, tt.env)
+			expand := func(path string) string {
+				if len(path) > 1 && path[:2] == "~/" {
+					path = filepath.Join(cl.Home, path[2:])
+				}
+				return filepath.FromSlash(path)
+			}
+
+			for _, fileName := range tt.files {
+				writeConfig(t, expand(fileName), "<configuration />")
+			}
+			// the XDG_CONFIG_HOME directory is shared by the cases
+			t.Cleanup(func() { os.RemoveAll(filepath.Join(xdgHome, "NuGet")) })
+
+			if got, want := cl.UserConfigFile(), expand(tt.want); got != want {
+				t.Errorf("UserConfigFile() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestConfigLocationsAdditionalUserConfigFiles(t *testing.T) {
+	cl := newFakeLocations(t, nil)
+
+	dir := filepath.Join(cl.Home, ".nuget", "NuGet", "config")
+	for _, name := range []string{"b.config", "a.config", "Z.config", "notes.txt"} {
+		writeConfig(t, filepath.Join(dir, name), "<configuration />")
+	}
+
+	want := []string{
+		filepath.Join(dir, "Z.config"),
+		filepath.Join(dir, "a.config"),
+		filepath.Join(dir, "b.config"),
+	}
+	if got := cl.AdditionalUserConfigFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AdditionalUserConfigFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigLocationsMachineConfig(t *testing.T) {
+	common := t.TempDir()
+
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{name: "linux", goos: "linux", want: "/etc/opt/NuGet/Config"},
+		{name: "darwin", goos: "darwin", want: "/Library/Application Support/NuGet/Config"},
+		{
+			name: "NUGET_COMMON_APPLICATION_DATA",
+			goos: "linux",
+			env:  map[string]string{"NUGET_COMMON_APPLICATION_DATA": common},
+			want: common + "/NuGet/Config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := newFakeLocations(t, tt.env)
+			cl.GOOS = tt.goos
+
+			if got, want := cl.MachineConfigDir(), filepath.FromSlash(tt.want); got != want {
+				t.Errorf("MachineConfigDir() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	cl := newFakeLocations(t, map[string]string{"NUGET_COMMON_APPLICATION_DATA": common})
+	dir := filepath.Join(common, "NuGet", "Config")
+	writeConfig(t, filepath.Join(dir, "Vendor.config"), "<configuration />")
+	writeConfig(t, filepath.Join(dir, "Company.config"), "<configuration />")
+
+	want := []string{filepath.Join(dir, "Company.config"), filepath.Join(dir, "Vendor.config")}
+	if got := cl.MachineConfigFiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MachineConfigFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigFinderSearchUserConfigPriority(t *testing.T) {
+	// an empty common application data folder hides the machine-wide configuration of the host
+	cl := newFakeLocations(t, map[string]string{"NUGET_COMMON_APPLICATION_DATA": t.TempDir()})
+
+	dir := filepath.Join(cl.Home, ".nuget", "NuGet")
+	files := map[string]string{
+		"NuGet.Config":    "https://user.example/v3/index.json",
+		"config/a.config": "https://a.example/v3/index.json",
+		"config/b.config": "https://b.example/v3/index.json",
+	}
+	for name, source := range files {
+		writeConfig(t, filepath.Join(dir, filepath.FromSlash(name)),
+			`<configuration><packageSources><add key="feed" value="`+source+`" /></packageSources></configuration>`)
+	}
+
+	settings, err := NewNugetConfigFinder().WithLocations(cl).Search()
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	// the user NuGet.Config overrides the additional files, which are applied in order
+	sources := settings.EnabledPackageSources()
+	if len(sources) != 1 || sources[0].Path != files["NuGet.Config"] {
+		t.Fatalf("EnabledPackageSources() = %+v, want the user NuGet.Config source", sources)
+	}
+
+	var got []string
+	for _, file := range settings.Files {
+		got = append(got, file.Path)
+	}
+	want := []string{
+		filepath.Join(dir, "config", "b.config"),
+		filepath.Join(dir, "config", "a.config"),
+		filepath.Join(dir, "NuGet.Config"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestConfigFinderSearchDisabledSources(t *testing.T) {
+	cl := newFakeLocations(t, map[string]string{"NUGET_COMMON_APPLICATION_DATA": t.TempDir()})
+
+	writeConfig(t, filepath.Join(cl.Home, ".nuget", "NuGet", "NuGet.Config"), `<configuration>
+  <packageSources>
+    <clear />
+    <add key="feed" value="https://feed.example/v3/index.json" />
+  </packageSources>
+  <disabledPackageSources>
+    <add key="feed" value="true" />
+  </disabledPackageSources>
+</configuration>`)
+
+	// a configuration without enabled sources is still listed by the sources command
+	settings, err := NewNugetConfigFinder().WithLocations(cl).Search()
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	sources := settings.PackageSources()
+	if len(sources) != 1 || !sources[0].Disabled {
+		t.Errorf("PackageSources() = %+v, want the disabled feed", sources)
+	}
+	if enabled := settings.EnabledPackageSources(); len(enabled) != 0 {
+		t.Errorf("EnabledPackageSources() = %+v, want none", enabled)
+	}
+}
@@ -0,0 +1,102 @@
+package nuget
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ApiKeyHeader is the header used to send the password of credentials without a user name to a package source.
+const ApiKeyHeader = "X-NuGet-ApiKey"
+
+var encodedXMLName = regexp.MustCompile(`_x([0-9A-Fa-f]{4})_`)
+
+type PackageSourceCredentials struct {
+	Username string
+	// Password is read from ClearTextPassword only, encrypted passwords are not supported.
+	Password                 string
+	ValidAuthenticationTypes []string
+}
+
+// PackageSourceCredentials returns the effective credentials keyed by lower-cased source name.
+func (s *Settings) PackageSourceCredentials() map[string]*PackageSourceCredentials {
+	credentials := make(map[string]*PackageSourceCredentials)
+
+	for _, file := range s.Files {
+		for _, n := range file.section("packageSourceCredentials") {
+			if n.Data == "clear" {
+				credentials = make(map[string]*PackageSourceCredentials)
+				continue
+			}
+
+			c := &PackageSourceCredentials{}
+			for a := n.FirstChild; a != nil; a = a.NextSibling {
+				if a.Data != "add" {
+					continue
+				}
+
+				value := expandEnvironment(a.SelectAttr("value"))
+
+				switch strings.ToLower(a.SelectAttr("key")) {
+				case "username":
+					c.Username = value
+				case "cleartextpassword":
+					c.Password = value
+				case "validauthenticationtypes":
+					for _, t := range strings.Split(value, ",") {
+						if t = strings.TrimSpace(t); t != "" {
+							c.ValidAuthenticationTypes = append(c.ValidAuthenticationTypes, strings.ToLower(t))
+						}
+					}
+				}
+			}
+
+			credentials[strings.ToLower(decodeXMLName(n.Data))] = c
+		}
+	}
+
+	return credentials
+}
+
+// decodeXMLName decodes source names stored as element names, e.g. "My_x0020_Feed" becomes "My Feed".
+func decodeXMLName(name string) string {
+	return encodedXMLName.ReplaceAllStringFunc(name, func(match string) string {
+		code, err := strconv.ParseUint(match[2:6], 16, 32)
+		if err != nil {
+			return match
+		}
+		return string(rune(code))
+	})
+}
+
+// allows reports whether the authentication type may be used. All types are allowed if none are listed.
+func (c *PackageSourceCredentials) allows(authType string) bool {
+	if len(c.ValidAuthenticationTypes) == 0 {
+		return true
+	}
+
+	for _, t := range c.ValidAuthenticationTypes {
+		if t == authType {
+			return true
+		}
+	}
+	return false
+}
+
+// apply adds the credentials to the request. With a user name the password is sent using basic
+// authentication if ValidAuthenticationTypes allows it; other authentication types are not supported,
+// so nothing is sent then. Without a user name the password is sent as an API key in the
+// X-NuGet-ApiKey header, which some feeds accept instead of basic authentication.
+func (c *PackageSourceCredentials) apply(r *http.Request) {
+	if c.Username != "" {
+		if c.allows("basic") {
+			r.SetBasicAuth(c.Username, c.Password)
+		}
+		return
+	}
+
+	if c.Password != "" {
+		r.Header.Set(ApiKeyHeader, c.Password)
+	}
+}
@@ -0,0 +1,36 @@
+package nuget
+
+import (
+	"os"
+	"regexp"
+)
+
+var (
+	windowsVariable = regexp.MustCompile(`%([^%]+)%`)
+	unixVariable    = regexp.MustCompile(`\$(?:\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))`)
+)
+
+// expandEnvironment replaces %VAR%, $VAR and ${VAR} references with environment variable values.
+// References to undefined variables are left unchanged.
+func expandEnvironment(value string) string {
+	value = windowsVariable.ReplaceAllStringFunc(value, func(match string) string {
+		if v, ok := os.LookupEnv(match[1 : len(match)-1]); ok {
+			return v
+		}
+		return match
+	})
+
+	return unixVariable.ReplaceAllStringFunc(value, func(match string) string {
+		sub := unixVariable.FindStringSubmatch(match)
+
+		name := sub[1]
+		if name == "" {
+			name = sub[2]
+		}
+
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		return match
+	})
+}
@@ -0,0 +1,36 @@
+package nuget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FlatContainerNuspec downloads the manifest of the exact package version from the V3 PackageBaseAddress resource.
+func (c *Client) FlatContainerNuspec(ctx context.Context, source PackageSource, id string, version string) (*Nuspec, error) {
+	index, err := c.ServiceIndex(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if index.PackageBaseAddress == "" {
+		return nil, fmt.Errorf("package base address: %w", ErrResourceNotFound)
+	}
+
+	id = strings.ToLower(id)
+	version = strings.ToLower(NormalizeVersion(version))
+
+	nuspecUrl := strings.TrimSuffix(index.PackageBaseAddress, "/") + "/" + id + "/" + version + "/" + id + ".nuspec"
+
+	r, err := c.getCached(ctx, source, nuspecUrl, cacheNuspec)
+	if err != nil {
+		if errors.Is(err, errNotFoundStatus) {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	return ReadNuspec(r.Body)
+}
@@ -0,0 +1,148 @@
+package nuget
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalFeed reads package metadata from a local folder or file share package source.
+// Both flat ({id}.{version}.nupkg) and hierarchical ({id}/{version}/{id}.{version}.nupkg) layouts are supported.
+type LocalFeed struct {
+	root string
+}
+
+// NewLocalFeed returns a new instance of LocalFeed.
+func NewLocalFeed(root string) *LocalFeed {
+	return &LocalFeed{root: root}
+}
+
+// Search returns the metadata of every version of the package found in the feed.
+func (lf *LocalFeed) Search(ctx context.Context, id string) (*ResponseQuery, error) {
+	entries, err := os.ReadDir(lf.root)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ResponseQuery{}
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		name := e.Name()
+
+		if e.IsDir() && strings.EqualFold(name, id) {
+			versions, err := os.ReadDir(filepath.Join(lf.root, name))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, v := range versions {
+				if !v.IsDir() {
+					continue
+				}
+
+				if data, ok := lf.readVersionDir(filepath.Join(lf.root, name, v.Name()), id); ok {
+					response.Data = append(response.Data, data)
+				}
+			}
+			continue
+		}
+
+		if !e.IsDir() && isPackageFileOf(name, id) {
+			if data, ok := lf.readPackage(filepath.Join(lf.root, name), id); ok {
+				response.Data = append(response.Data, data)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// readVersionDir reads an extracted .nuspec or the .nupkg from a hierarchical version directory.
+func (lf *LocalFeed) readVersionDir(dir string, id string) (PackageData, bool) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return PackageData{}, false
+	}
+
+	for _, f := range files {
+		if strings.EqualFold(f.Name(), id+".nuspec") {
+			if data, ok := readNuspecFile(filepath.Join(dir, f.Name()), id); ok {
+				return data, true
+			}
+		}
+	}
+
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(strings.ToLower(f.Name()), ".nupkg") {
+			if data, ok := lf.readPackage(filepath.Join(dir, f.Name()), id); ok {
+				return data, true
+			}
+		}
+	}
+
+	return PackageData{}, false
+}
+
+// readPackage reads the .nuspec stored in the root of a .nupkg archive.
+func (lf *LocalFeed) readPackage(fileName string, id string) (PackageData, bool) {
+	archive, err := zip.OpenReader(fileName)
+	if err != nil {
+		return PackageData{}, false
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if strings.Contains(f.Name, "/") || !strings.HasSuffix(strings.ToLower(f.Name), ".nuspec") {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return PackageData{}, false
+		}
+
+		nuspec, err := ReadNuspec(r)
+		r.Close()
+		if err != nil || !strings.EqualFold(nuspec.Metadata.Id, id) {
+			return PackageData{}, false
+		}
+
+		return nuspec.PackageData(), true
+	}
+
+	return PackageData{}, false
+}
+
+func readNuspecFile(fileName string, id string) (PackageData, bool) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return PackageData{}, false
+	}
+	defer f.Close()
+
+	nuspec, err := ReadNuspec(f)
+	if err != nil || !strings.EqualFold(nuspec.Metadata.Id, id) {
+		return PackageData{}, false
+	}
+
+	return nuspec.PackageData(), true
+}
+
+// isPackageFileOf reports whether the file name looks like {id}.{version}.nupkg.
+func isPackageFileOf(name string, id string) bool {
+	lower := strings.ToLower(name)
+	prefix := strings.ToLower(id) + "."
+
+	if !strings.HasPrefix(lower, prefix) || !strings.HasSuffix(lower, ".nupkg") {
+		return false
+	}
+
+	rest := lower[len(prefix):]
+	return len(rest) > 0 && rest[0] >= '0' && rest[0] <= '9'
+}
@@ -0,0 +1,159 @@
+package nuget
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Nuspec is the package manifest stored inside a .nupkg file.
+type Nuspec struct {
+	XMLName  xml.Name       `xml:"package"`
+	Metadata NuspecMetadata `xml:"metadata"`
+}
+
+type NuspecMetadata struct {
+	MinClientVersion         string                    `xml:"minClientVersion,attr"`
+	Id                       string                    `xml:"id"`
+	Version                  string                    `xml:"version"`
+	Title                    string                    `xml:"title"`
+	Authors                  string                    `xml:"authors"`
+	Owners                   string                    `xml:"owners"`
+	Description              string                    `xml:"description"`
+	Summary                  string                    `xml:"summary"`
+	ReleaseNotes             string                    `xml:"releaseNotes"`
+	Copyright                string                    `xml:"copyright"`
+	Language                 string                    `xml:"language"`
+	Tags                     string                    `xml:"tags"`
+	LicenseUrl               string                    `xml:"licenseUrl"`
+	ProjectUrl               string                    `xml:"projectUrl"`
+	IconUrl                  string                    `xml:"iconUrl"`
+	Icon                     string                    `xml:"icon"`
+	Readme                   string                    `xml:"readme"`
+	RequireLicenseAcceptance bool                      `xml:"requireLicenseAcceptance"`
+	DevelopmentDependency    bool                      `xml:"developmentDependency"`
+	Serviceable              bool                      `xml:"serviceable"`
+	License                  NuspecLicense             `xml:"license"`
+	Repository               NuspecRepository          `xml:"repository"`
+	PackageTypes             []NuspecPackageType       `xml:"packageTypes>packageType"`
+	Dependencies             NuspecDependencies        `xml:"dependencies"`
+	FrameworkAssemblies      []NuspecFrameworkAssembly `xml:"frameworkAssemblies>frameworkAssembly"`
+	FrameworkReferences      []NuspecFrameworkGroup    `xml:"frameworkReferences>group"`
+}
+
+type NuspecLicense struct {
+	Type    string `xml:"type,attr"`
+	Version string `xml:"version,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type NuspecRepository struct {
+	Type   string `xml:"type,attr"`
+	Url    string `xml:"url,attr"`
+	Branch string `xml:"branch,attr"`
+	Commit string `xml:"commit,attr"`
+}
+
+type NuspecPackageType struct {
+	Name    string `xml:"name,attr"`
+	Version string `xml:"version,attr"`
+}
+
+// NuspecDependencies contains either a flat list of dependencies or dependency groups per target framework.
+type NuspecDependencies struct {
+	Dependencies []NuspecDependency      `xml:"dependency"`
+	Groups       []NuspecDependencyGroup `xml:"group"`
+}
+
+type NuspecDependencyGroup struct {
+	TargetFramework string             `xml:"targetFramework,attr"`
+	Dependencies    []NuspecDependency `xml:"dependency"`
+}
+
+type NuspecDependency struct {
+	Id      string `xml:"id,attr"`
+	Version string `xml:"version,attr"`
+	Include string `xml:"include,attr"`
+	Exclude string `xml:"exclude,attr"`
+}
+
+type NuspecFrameworkAssembly struct {
+	AssemblyName    string `xml:"assemblyName,attr"`
+	TargetFramework string `xml:"targetFramework,attr"`
+}
+
+type NuspecFrameworkGroup struct {
+	TargetFramework     string `xml:"targetFramework,attr"`
+	FrameworkReferences []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"frameworkReference"`
+}
+
+// ReadNuspec parses a package manifest. Namespaces are ignored, so all nuspec schema versions are accepted.
+func ReadNuspec(r io.Reader) (*Nuspec, error) {
+	nuspec := &Nuspec{}
+	if err := xml.NewDecoder(r).Decode(nuspec); err != nil {
+		return nil, err
+	}
+	return nuspec, nil
+}
+
+// DependencyGroups returns the dependencies grouped by target framework. A flat dependency list
+// is returned as a single group without a target framework.
+func (n *Nuspec) DependencyGroups() []DependencyGroup {
+	var groups []DependencyGroup
+
+	deps := n.Metadata.Dependencies
+	if len(deps.Dependencies) > 0 {
+		groups = append(groups, DependencyGroup{Dependencies: convertDependencies(deps.Dependencies)})
+	}
+
+	for _, g := range deps.Groups {
+		groups = append(groups, DependencyGroup{
+			TargetFramework: g.TargetFramework,
+			Dependencies:    convertDependencies(g.Dependencies),
+		})
+	}
+
+	return groups
+}
+
+func convertDependencies(deps []NuspecDependency) []Dependency {
+	var result []Dependency
+	for _, d := range deps {
+		result = append(result, Dependency{Id: d.Id, Range: d.Version})
+	}
+	return result
+}
+
+// PackageData converts the manifest to the package data returned by the package sources.
+func (n *Nuspec) PackageData() PackageData {
+	m := n.Metadata
+
+	data := PackageData{
+		Id:               m.Id,
+		Version:          m.Version,
+		Description:      m.Description,
+		Title:            m.Title,
+		Summary:          m.Summary,
+		LicenseUrl:       m.LicenseUrl,
+		ProjectUrl:       m.ProjectUrl,
+		Copyright:        m.Copyright,
+		ReleaseNotes:     m.ReleaseNotes,
+		RepositoryUrl:    m.Repository.Url,
+		DependencyGroups: n.DependencyGroups(),
+	}
+
+	if m.License.Type == "expression" {
+		data.LicenseExpression = strings.TrimSpace(m.License.Value)
+	}
+
+	for _, a := range strings.Split(m.Authors, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			data.Authors.Values = append(data.Authors.Values, a)
+		}
+	}
+
+	data.Tags.Values = strings.Fields(m.Tags)
+	return data
+}
@@ -0,0 +1,49 @@
+package nuget
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PackagesFolder reads package metadata from the global packages folder restore extracts packages to.
+type PackagesFolder struct {
+	root string
+}
+
+// NewPackagesFolder returns a new instance of PackagesFolder.
+func NewPackagesFolder(root string) *PackagesFolder {
+	return &PackagesFolder{root: root}
+}
+
+// Find returns the metadata of the exact package version, if it has been restored.
+func (pf *PackagesFolder) Find(id string, version string) (PackageData, bool) {
+	if pf.root == "" || !IsExactVersion(version) {
+		return PackageData{}, false
+	}
+
+	id = strings.ToLower(id)
+	version = strings.ToLower(NormalizeVersion(version))
+
+	return readNuspecFile(filepath.Join(pf.root, id, version, id+".nuspec"), id)
+}
+
+// GlobalPackagesFolder returns the global packages folder from the NUGET_PACKAGES environment variable,
+// the globalPackagesFolder configuration value or the default location in the user's home directory.
+func (s *Settings) GlobalPackagesFolder() string {
+	if folder := os.Getenv("NUGET_PACKAGES"); folder != "" {
+		return folder
+	}
+
+	for _, item := range s.items("config") {
+		if strings.EqualFold(item.Key, "globalPackagesFolder") && item.Value != "" {
+			return item.File.resolvePath(item.Value)
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".nuget", "packages")
+}
@@ -0,0 +1,91 @@
+package nuget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrPackageNotFound is returned when a package source does not contain the package version.
+var ErrPackageNotFound = errors.New("package not found")
+
+// ErrResourceNotFound is returned when a package source does not provide a required resource.
+var ErrResourceNotFound = errors.New("resource not found")
+
+type RegistrationIndex struct {
+	Count int                `json:"count"`
+	Items []RegistrationPage `json:"items"`
+}
+
+type RegistrationPage struct {
+	Id    string             `json:"@id"`
+	Count int                `json:"count"`
+	Lower string             `json:"lower"`
+	Upper string             `json:"upper"`
+	Items []RegistrationLeaf `json:"items"`
+}
+
+type RegistrationLeaf struct {
+	Id           string      `json:"@id"`
+	CatalogEntry PackageData `json:"catalogEntry"`
+}
+
+// Registration returns the catalog entry of the exact package version from the V3 registration resource.
+// Pages which are not inlined in the registration index are fetched on demand.
+func (c *Client) Registration(ctx context.Context, source PackageSource, id string, version string) (*PackageData, error) {
+	index, err := c.ServiceIndex(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if index.RegistrationsBaseUrl == "" {
+		return nil, fmt.Errorf("registrations base url: %w", ErrResourceNotFound)
+	}
+
+	indexUrl := strings.TrimSuffix(index.RegistrationsBaseUrl, "/") + "/" + strings.ToLower(id) + "/index.json"
+
+	registration := &RegistrationIndex{}
+	if err := c.getJSON(ctx, source, indexUrl, registration); err != nil {
+		if errors.Is(err, errNotFoundStatus) {
+			return nil, ErrPackageNotFound
+		}
+		return nil, err
+	}
+
+	for _, page := range registration.Items {
+		if page.Lower != "" && CompareVersions(version, page.Lower) < 0 {
+			continue
+		}
+		if page.Upper != "" && CompareVersions(version, page.Upper) > 0 {
+			continue
+		}
+
+		if page.Items == nil {
+			if err := c.getJSON(ctx, source, page.Id, &page); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, leaf := range page.Items {
+			if SameVersion(leaf.CatalogEntry.Version, version) {
+				entry := leaf.CatalogEntry
+				return &entry, nil
+			}
+		}
+	}
+
+	return nil, ErrPackageNotFound
+}
+
+// getJSON downloads and decodes a JSON document of the registration resource.
+func (c *Client) getJSON(ctx context.Context, source PackageSource, requestUrl string, v interface{}) error {
+	r, err := c.getCached(ctx, source, requestUrl, cacheRegistration)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	return json.NewDecoder(r.Body).Decode(v)
+}
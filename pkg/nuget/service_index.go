@@ -0,0 +1,123 @@
+package nuget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sync"
+)
+
+// Resource types of the V3 service index, ordered from the most to the least preferred version.
+var (
+	SearchQueryServiceTypes = []string{
+		"SearchQueryService/3.5.0",
+		"SearchQueryService/3.0.0-rc",
+		"SearchQueryService/3.0.0-beta",
+		"SearchQueryService",
+	}
+	RegistrationsBaseUrlTypes = []string{
+		"RegistrationsBaseUrl/3.6.0",
+		"RegistrationsBaseUrl/3.4.0",
+		"RegistrationsBaseUrl/3.0.0-rc",
+		"RegistrationsBaseUrl/3.0.0-beta",
+		"RegistrationsBaseUrl",
+	}
+	PackageBaseAddressTypes = []string{
+		"PackageBaseAddress/3.0.0",
+	}
+	VulnerabilityInfoTypes = []string{
+		"VulnerabilityInfo/6.7.0",
+	}
+	PackageDetailsUriTemplateTypes = []string{
+		"PackageDetailsUriTemplate/5.1.0",
+	}
+)
+
+// ServiceIndex describes the protocol and resources of an HTTP package source.
+type ServiceIndex struct {
+	Protocol                  string
+	SearchQueryService        string
+	RegistrationsBaseUrl      string
+	PackageBaseAddress        string
+	VulnerabilityInfo         string
+	PackageDetailsUriTemplate string
+	Resources                 []Resource
+}
+
+type serviceIndexEntry struct {
+	mu    sync.Mutex
+	done  bool
+	index *ServiceIndex
+	err   error
+}
+
+// ServiceIndex resolves the source once and returns the cached result, including a failure, afterwards.
+// Failures caused by a canceled or expired context are not cached.
+func (c *Client) ServiceIndex(ctx context.Context, source PackageSource) (*ServiceIndex, error) {
+	c.mu.Lock()
+	entry, ok := c.indexes[source.Path]
+	if !ok {
+		entry = &serviceIndexEntry{}
+		c.indexes[source.Path] = entry
+	}
+	c.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.done {
+		entry.index, entry.err = c.fetchServiceIndex(ctx, source)
+		entry.done = ctx.Err() == nil
+	}
+
+	return entry.index, entry.err
+}
+
+// fetchServiceIndex downloads the source URL and detects whether it is a V2 feed or a V3 service index.
+func (c *Client) fetchServiceIndex(ctx context.Context, source PackageSource) (*ServiceIndex, error) {
+	r, err := c.getCached(ctx, source, source.Path, cacheServiceIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	switch mediaType {
+	case "application/xml", "application/atom+xml":
+		return &ServiceIndex{Protocol: ProtocolV2}, nil
+	case "application/json":
+		response := &ResponseResources{}
+		if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+			return nil, err
+		}
+
+		return &ServiceIndex{
+			Protocol:                  ProtocolV3,
+			SearchQueryService:        findResource(response.Resources, SearchQueryServiceTypes),
+			RegistrationsBaseUrl:      findResource(response.Resources, RegistrationsBaseUrlTypes),
+			PackageBaseAddress:        findResource(response.Resources, PackageBaseAddressTypes),
+			VulnerabilityInfo:         findResource(response.Resources, VulnerabilityInfoTypes),
+			PackageDetailsUriTemplate: findResource(response.Resources, PackageDetailsUriTemplateTypes),
+			Resources:                 response.Resources,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown media type: %s", mediaType)
+}
+
+// findResource returns the URL of the most preferred resource type available.
+func findResource(resources []Resource, types []string) string {
+	for _, t := range types {
+		for _, r := range resources {
+			if r.Type == t {
+				return r.Id
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,236 @@
+package nuget
+
+import (
+	"github.com/antchfx/xmlquery"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultUserConfig mirrors the user configuration NuGet creates when none exists.
+const defaultUserConfig = `<?xml version="1.0" encoding="utf-8"?>
+<configuration>
+  <packageSources>
+    <add key="nuget.org" value="https://api.nuget.org/v3/index.json" protocolVersion="3" />
+  </packageSources>
+</configuration>`
+
+type ConfigFile struct {
+	Path string
+	doc  *xmlquery.Node
+}
+
+// ReadConfigFile parses a single NuGet configuration file.
+func ReadConfigFile(path string) (*ConfigFile, error) {
+	reader, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	doc, err := xmlquery.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigFile{Path: path, doc: doc}, nil
+}
+
+// newDefaultConfigFile returns the implicit user configuration with the nuget.org source.
+func newDefaultConfigFile(path string) *ConfigFile {
+	doc, _ := xmlquery.Parse(strings.NewReader(defaultUserConfig))
+	return &ConfigFile{Path: path, doc: doc}
+}
+
+// section returns the child elements of the given configuration section.
+func (cf *ConfigFile) section(name string) []*xmlquery.Node {
+	var nodes []*xmlquery.Node
+
+	for _, s := range xmlquery.Find(cf.doc, "/configuration/"+name) {
+		for n := s.FirstChild; n != nil; n = n.NextSibling {
+			if n.Type == xmlquery.ElementNode {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+
+	return nodes
+}
+
+// resolvePath resolves a relative local path against the directory of the configuration file.
+// URLs and absolute paths are returned unchanged.
+func (cf *ConfigFile) resolvePath(value string) string {
+	if !(PackageSource{Path: value}).IsLocal() || strings.Contains(value, "://") {
+		return value
+	}
+
+	value = filepath.FromSlash(strings.Replace(value, `\`, "/", -1))
+	if filepath.IsAbs(value) || filepath.VolumeName(value) != "" {
+		return value
+	}
+
+	return filepath.Join(filepath.Dir(cf.Path), value)
+}
+
+// CommandLineSource is the file name reported for package sources passed on the command line.
+const CommandLineSource = "command line"
+
+// Settings contains NuGet configuration files ordered from the lowest to the highest priority.
+type Settings struct {
+	Files []*ConfigFile
+	// Sources are additional package sources queried before the configured ones
+	Sources []string
+	// NoDefaultSources excludes the package sources declared in configuration files
+	NoDefaultSources bool
+}
+
+type settingItem struct {
+	Key   string
+	Value string
+	Node  *xmlquery.Node
+	File  *ConfigFile
+}
+
+// items merges the keyed, remove and clear elements of a section across all configuration files.
+// Items from files with a higher priority override items with the same key.
+func (s *Settings) items(section string) []settingItem {
+	var items []settingItem
+
+	for _, file := range s.Files {
+		for _, n := range file.section(section) {
+			key := n.SelectAttr("key")
+
+			switch n.Data {
+			case "clear":
+				items = nil
+			case "remove":
+				items = removeItem(items, key)
+			default:
+				item := settingItem{Key: key, Value: expandEnvironment(n.SelectAttr("value")), Node: n, File: file}
+
+				found := false
+				for i := range items {
+					if strings.EqualFold(items[i].Key, key) {
+						items[i] = item
+						found = true
+						break
+					}
+				}
+
+				if !found {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+
+	return items
+}
+
+func removeItem(items []settingItem, key string) []settingItem {
+	for i := range items {
+		if strings.EqualFold(items[i].Key, key) {
+			return append(items[:i], items[i+1:]...)
+		}
+	}
+	return items
+}
+
+// DisabledPackageSources returns the names of disabled package sources.
+func (s *Settings) DisabledPackageSources() map[string]bool {
+	disabled := make(map[string]bool)
+
+	for _, item := range s.items("disabledPackageSources") {
+		if strings.EqualFold(strings.TrimSpace(item.Value), "true") {
+			disabled[strings.ToLower(item.Key)] = true
+		}
+	}
+
+	return disabled
+}
+
+// PackageSources returns the effective package sources, including disabled ones.
+func (s *Settings) PackageSources() []PackageSource {
+	var packageSources []PackageSource
+
+	if s.NoDefaultSources && len(s.Sources) == 0 {
+		return nil
+	}
+
+	disabled := s.DisabledPackageSources()
+	credentials := s.PackageSourceCredentials()
+
+	for _, item := range s.items("packageSources") {
+		if item.Value == "" {
+			continue
+		}
+
+		packageSources = append(packageSources, PackageSource{
+			FileName:        item.File.Path,
+			SourceName:      item.Key,
+			Path:            item.File.resolvePath(item.Value),
+			ProtocolVersion: item.Node.SelectAttr("protocolVersion"),
+			Disabled:        disabled[strings.ToLower(item.Key)],
+			Credentials:     credentials[strings.ToLower(item.Key)],
+		})
+	}
+
+	if len(s.Sources) == 0 {
+		return packageSources
+	}
+
+	var result []PackageSource
+
+	for _, value := range s.Sources {
+		source := PackageSource{
+			FileName:   CommandLineSource,
+			SourceName: value,
+			Path:       value,
+		}
+
+		// a configured source with the same location keeps its name and credentials
+		for _, ps := range packageSources {
+			if strings.EqualFold(strings.TrimSuffix(ps.Path, "/"), strings.TrimSuffix(value, "/")) {
+				source.SourceName = ps.SourceName
+				source.ProtocolVersion = ps.ProtocolVersion
+				source.Credentials = ps.Credentials
+				break
+			}
+		}
+
+		result = append(result, source)
+	}
+
+	if s.NoDefaultSources {
+		return result
+	}
+
+	for _, ps := range packageSources {
+		duplicate := false
+		for _, r := range result {
+			if strings.EqualFold(r.SourceName, ps.SourceName) {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			result = append(result, ps)
+		}
+	}
+
+	return result
+}
+
+// EnabledPackageSources returns the package sources restore would use.
+func (s *Settings) EnabledPackageSources() []PackageSource {
+	var packageSources []PackageSource
+
+	for _, ps := range s.PackageSources() {
+		if !ps.Disabled {
+			packageSources = append(packageSources, ps)
+		}
+	}
+
+	return packageSources
+}
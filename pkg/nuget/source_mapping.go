@@ -0,0 +1,78 @@
+package nuget
+
+import "strings"
+
+// PackageSourceMapping routes package ids to package sources by id patterns.
+type PackageSourceMapping struct {
+	// patterns maps lower-cased id patterns to source names
+	patterns map[string][]string
+}
+
+// PackageSourceMapping returns the effective package source mapping.
+func (s *Settings) PackageSourceMapping() *PackageSourceMapping {
+	m := &PackageSourceMapping{patterns: make(map[string][]string)}
+
+	for _, item := range s.items("packageSourceMapping") {
+		if item.Node.Data != "packageSource" {
+			continue
+		}
+
+		for n := item.Node.FirstChild; n != nil; n = n.NextSibling {
+			if n.Data != "package" {
+				continue
+			}
+
+			pattern := strings.ToLower(strings.TrimSpace(n.SelectAttr("pattern")))
+			if pattern == "" {
+				continue
+			}
+
+			m.patterns[pattern] = append(m.patterns[pattern], item.Key)
+		}
+	}
+
+	return m
+}
+
+// IsEnabled reports whether any mapping patterns are configured.
+func (m *PackageSourceMapping) IsEnabled() bool {
+	return m != nil && len(m.patterns) > 0
+}
+
+// SourcesFor returns the names of the sources mapped to the package id.
+// An exact id pattern wins over prefix patterns, and the longest prefix pattern wins over shorter ones.
+func (m *PackageSourceMapping) SourcesFor(id string) []string {
+	if !m.IsEnabled() {
+		return nil
+	}
+
+	id = strings.ToLower(id)
+
+	if sources, ok := m.patterns[id]; ok {
+		return sources
+	}
+
+	var best string
+	var found bool
+
+	for pattern := range m.patterns {
+		if !strings.HasSuffix(pattern, "*") {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(pattern, "*")
+		if !strings.HasPrefix(id, prefix) {
+			continue
+		}
+
+		if !found || len(pattern) > len(best) {
+			best = pattern
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return m.patterns[best]
+}
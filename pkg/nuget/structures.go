@@ -3,7 +3,10 @@ package nuget
 import (
 	"encoding/json"
 	"encoding/xml"
+	"net/url"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type ResponseResources struct {
@@ -19,6 +22,22 @@ type Resource struct {
 type ResponseQuery struct {
 	XMLName xml.Name      `xml:"feed"`
 	Data    []PackageData `json:"data" xml:"entry"`
+	Links   []FeedLink    `json:"-" xml:"link"`
+}
+
+type FeedLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// NextLink returns the URL of the next page of a V2 feed, if any.
+func (rq *ResponseQuery) NextLink() string {
+	for _, l := range rq.Links {
+		if l.Rel == "next" {
+			return l.Href
+		}
+	}
+	return ""
 }
 
 type PackageAuthors struct {
@@ -41,8 +60,22 @@ func (pa *PackageAuthors) UnmarshalXML(d *xml.Decoder, start xml.StartElement) e
 	return nil
 }
 
+// UnmarshalJSON accepts an array of authors, as returned by search, or a comma separated string,
+// as returned by registration catalog entries.
 func (pa *PackageAuthors) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, &pa.Values)
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return json.Unmarshal(data, &pa.Values)
+	}
+
+	pa.Values = nil
+	for _, a := range strings.Split(value, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			pa.Values = append(pa.Values, a)
+		}
+	}
+
+	return nil
 }
 
 type PackageTags struct {
@@ -64,20 +97,94 @@ func (pt *PackageTags) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 	return nil
 }
 
+// UnmarshalJSON accepts an array of tags or a space separated string.
 func (pt *PackageTags) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, &pt.Values)
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return json.Unmarshal(data, &pt.Values)
+	}
+
+	pt.Values = strings.Fields(value)
+	return nil
 }
 
 type PackageData struct {
-	Id          string         `json:"id" xml:"properties>Id"`
-	Version     string         `json:"version" xml:"properties>NormalizedVersion"`
-	Description string         `json:"description" xml:"properties>Description"`
-	Title       string         `json:"title" xml:"title"`
-	Summary     string         `json:"summary" xml:"summary"`
-	Authors     PackageAuthors `json:"authors" xml:"author>name"`
-	Tags        PackageTags    `json:"tags" xml:"properties>Tags"`
-	LicenseUrl  string         `json:"licenseUrl" xml:"properties>LicenseUrl"`
-	ProjectUrl  string         `json:"projectUrl" xml:"properties>ProjectUrl"`
+	Id                string         `json:"id" xml:"properties>Id"`
+	Version           string         `json:"version" xml:"properties>NormalizedVersion"`
+	Description       string         `json:"description" xml:"properties>Description"`
+	Title             string         `json:"title" xml:"title"`
+	Summary           string         `json:"summary" xml:"summary"`
+	Authors           PackageAuthors `json:"authors" xml:"author>name"`
+	Tags              PackageTags    `json:"tags" xml:"properties>Tags"`
+	LicenseUrl        string         `json:"licenseUrl" xml:"properties>LicenseUrl"`
+	ProjectUrl        string         `json:"projectUrl" xml:"properties>ProjectUrl"`
+	Copyright         string         `json:"copyright" xml:"properties>Copyright"`
+	ReleaseNotes      string         `json:"releaseNotes" xml:"properties>ReleaseNotes"`
+	LicenseExpression string         `json:"licenseExpression" xml:"-"`
+	// OriginalVersion is only set by V2 feeds, older servers do not provide NormalizedVersion
+	OriginalVersion string `json:"-" xml:"properties>Version"`
+	// RepositoryUrl and DependencyGroups are only known from package manifests
+	RepositoryUrl    string            `json:"-" xml:"-"`
+	DependencyGroups []DependencyGroup `json:"-" xml:"-"`
+}
+
+// normalizeVersion sets the normalized version from the original version when the feed did not provide it.
+func (pd *PackageData) normalizeVersion() {
+	if pd.Version == "" {
+		pd.Version = NormalizeVersion(pd.OriginalVersion)
+	}
+}
+
+// merge fills the fields missing from the data with the values of the package manifest, which are
+// authoritative for the fields only manifests contain.
+func (pd *PackageData) merge(m PackageData) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+
+	fill(&pd.Title, m.Title)
+	fill(&pd.Description, m.Description)
+	fill(&pd.Summary, m.Summary)
+	fill(&pd.LicenseUrl, m.LicenseUrl)
+	fill(&pd.ProjectUrl, m.ProjectUrl)
+	fill(&pd.Copyright, m.Copyright)
+	fill(&pd.ReleaseNotes, m.ReleaseNotes)
+	fill(&pd.LicenseExpression, m.LicenseExpression)
+
+	if len(pd.Authors.Values) == 0 {
+		pd.Authors = m.Authors
+	}
+	if len(pd.Tags.Values) == 0 {
+		pd.Tags = m.Tags
+	}
+
+	pd.RepositoryUrl = m.RepositoryUrl
+	pd.DependencyGroups = m.DependencyGroups
+}
+
+type DependencyGroup struct {
+	TargetFramework string
+	Dependencies    []Dependency
+}
+
+type Dependency struct {
+	Id    string
+	Range string
+}
+
+const (
+	ProtocolLocal = "local"
+	ProtocolV2    = "v2"
+	ProtocolV3    = "v3"
+)
+
+type SourceStatus struct {
+	Protocol  string
+	Reachable bool
+	Latency   time.Duration
+	Error     error
 }
 
 type PackageSource struct {
@@ -85,4 +192,24 @@ type PackageSource struct {
 	SourceName      string
 	Path            string
 	ProtocolVersion string
+	Disabled        bool
+	Credentials     *PackageSourceCredentials
+}
+
+// IsLocal reports whether the source is a local folder or file share rather than an HTTP feed.
+func (ps PackageSource) IsLocal() bool {
+	u, err := url.Parse(ps.Path)
+	if err != nil || len(u.Scheme) < 2 {
+		// an unparsable value or a drive letter, such as C:\packages
+		return true
+	}
+	return u.Scheme != "http" && u.Scheme != "https"
+}
+
+// LocalPath returns the folder of a local source, converting file:// URLs to paths.
+func (ps PackageSource) LocalPath() string {
+	if u, err := url.Parse(ps.Path); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	return ps.Path
 }
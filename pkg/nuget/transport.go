@@ -0,0 +1,306 @@
+package nuget
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout limits a single request to a package source unless configured otherwise.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultUserAgent identifies the scanner, its version and platform to package sources.
+var DefaultUserAgent = defaultUserAgent()
+
+// TransportOptions configures the HTTP connections to package sources.
+type TransportOptions struct {
+	// Proxy is the URL of the HTTP proxy. Without it the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are used.
+	Proxy         string
+	ProxyUser     string
+	ProxyPassword string
+	// NoProxy is a comma separated list of hosts and domains which are connected to directly,
+	// bypassing the configured proxy as well as the proxy of the environment.
+	NoProxy string
+	// CABundle is a PEM file with certificates trusted in addition to the system roots.
+	CABundle string
+	// Timeout limits each request, SourceTimeouts overrides it for the sources with the given names.
+	Timeout        time.Duration
+	SourceTimeouts map[string]time.Duration
+	UserAgent      string
+}
+
+// DefaultTransportOptions returns the options used by NewNugetClient.
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		Timeout:   DefaultTimeout,
+		UserAgent: DefaultUserAgent,
+	}
+}
+
+// TransportOptions returns the default transport options with the values of the config section.
+// The proxy is read from the http_proxy, http_proxy.user, http_proxy.password and no_proxy keys of NuGet.
+// NuGet has no keys for the other options, so ca_bundle, http_timeout, http_timeout.<source name>
+// and user_agent are read as well. A relative ca_bundle is resolved against its configuration file.
+func (s *Settings) TransportOptions() (TransportOptions, error) {
+	opts := DefaultTransportOptions()
+
+	for _, item := range s.items("config") {
+		key := strings.ToLower(item.Key)
+
+		switch {
+		case key == "http_proxy":
+			opts.Proxy = item.Value
+		case key == "http_proxy.user":
+			opts.ProxyUser = item.Value
+		case key == "http_proxy.password":
+			opts.ProxyPassword = item.Value
+		case key == "no_proxy":
+			opts.NoProxy = item.Value
+		case key == "ca_bundle":
+			opts.CABundle = item.File.resolvePath(item.Value)
+		case key == "user_agent":
+			opts.UserAgent = item.Value
+		case key == "http_timeout" || strings.HasPrefix(key, "http_timeout."):
+			timeout, err := time.ParseDuration(item.Value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s in %s: %w", item.Key, item.File.Path, err)
+			}
+
+			if key == "http_timeout" {
+				opts.Timeout = timeout
+				break
+			}
+
+			if opts.SourceTimeouts == nil {
+				opts.SourceTimeouts = make(map[string]time.Duration)
+			}
+			opts.SourceTimeouts[item.Key[len("http_timeout."):]] = timeout
+		}
+	}
+
+	return opts, nil
+}
+
+// newTransport creates the HTTP transport with the proxy and the trusted certificates of the options.
+func newTransport(opts TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if opts.Proxy != "" || opts.NoProxy != "" {
+		proxy, err := proxyFunc(opts)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
+	}
+
+	if opts.CABundle != "" {
+		roots, err := loadCABundle(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	return transport, nil
+}
+
+// proxyFromEnvironment reads the proxy from the environment, it is replaced by tests
+// because http.ProxyFromEnvironment reads the environment only once.
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+// proxyFunc returns the proxy of the options, or the proxy of the environment without one,
+// for all requests except those to the NoProxy hosts.
+func proxyFunc(opts TransportOptions) (func(*http.Request) (*url.URL, error), error) {
+	proxyURL := proxyFromEnvironment
+
+	if opts.Proxy != "" {
+		proxy := opts.Proxy
+		if !strings.Contains(proxy, "://") {
+			proxy = "http://" + proxy
+		}
+
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy address: %s", opts.Proxy)
+		}
+
+		if opts.ProxyUser != "" {
+			u.User = url.UserPassword(opts.ProxyUser, opts.ProxyPassword)
+		}
+
+		proxyURL = http.ProxyURL(u)
+	}
+
+	noProxy := parseNoProxy(opts.NoProxy)
+
+	return func(r *http.Request) (*url.URL, error) {
+		if noProxy.matches(r.URL) {
+			return nil, nil
+		}
+		return proxyURL(r)
+	}, nil
+}
+
+// noProxyList matches the hosts of a comma separated no_proxy value. Entries are host names, which also
+// match their subdomains, domains with a leading dot, IP addresses and CIDR ranges, each optionally
+// with a port. "*" matches every host. Like with NO_PROXY, loopback addresses are never proxied.
+type noProxyList struct {
+	all     bool
+	entries []noProxyEntry
+}
+
+type noProxyEntry struct {
+	host    string
+	port    string
+	network *net.IPNet
+}
+
+func parseNoProxy(value string) noProxyList {
+	var list noProxyList
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.entries = append(list.entries, noProxyEntry{network: network})
+			continue
+		}
+
+		var e noProxyEntry
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			e.host, e.port = host, port
+		} else {
+			e.host = strings.Trim(entry, "[]")
+		}
+
+		if strings.HasPrefix(e.host, "*.") {
+			e.host = e.host[1:]
+		}
+
+		list.entries = append(list.entries, e)
+	}
+
+	return list
+}
+
+// matches reports whether requests to the URL are sent directly instead of through the proxy.
+func (l noProxyList) matches(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	ip := net.ParseIP(host)
+
+	if l.all || host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	for _, e := range l.entries {
+		if e.port != "" && e.port != port {
+			continue
+		}
+
+		switch {
+		case e.network != nil:
+			if ip != nil && e.network.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(e.host, "."):
+			if strings.HasSuffix(host, e.host) {
+				return true
+			}
+		case host == e.host || strings.HasSuffix(host, "."+e.host):
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadCABundle returns the system certificate pool extended with the certificates of the PEM file.
+func loadCABundle(fileName string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", fileName)
+	}
+
+	return roots, nil
+}
+
+// timeout returns the request timeout of the source.
+func (opts TransportOptions) timeout(source PackageSource) time.Duration {
+	for name, timeout := range opts.SourceTimeouts {
+		if strings.EqualFold(name, source.SourceName) {
+			return timeout
+		}
+	}
+	return opts.Timeout
+}
+
+// cancelBody cancels the request context of a response once its body has been closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func defaultUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+
+	return fmt.Sprintf("go-nuget-list/%s (%s; %s; %s)", version, runtime.GOOS, runtime.GOARCH, runtime.Version())
+}
+
+// ParseSourceTimeout parses a per-source timeout in the form name=duration.
+func ParseSourceTimeout(value string) (string, time.Duration, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", 0, fmt.Errorf("source timeout must be in the form name=duration: %s", value)
+	}
+
+	timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return strings.TrimSpace(parts[0]), timeout, nil
+}
@@ -0,0 +1,163 @@
+package nuget
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// MaxV2UrlLength keeps batched V2 query URLs below the limits of common web servers and proxies.
+var MaxV2UrlLength = 2000
+
+type PackageIdentity struct {
+	Id      string
+	Version string
+}
+
+// key returns the lookup key of the identity, using the normalized version.
+func (pi PackageIdentity) key() string {
+	return strings.ToLower(pi.Id) + "/" + strings.ToLower(NormalizeVersion(pi.Version))
+}
+
+// v2Prefetch holds the results of batched V2 queries of a single source.
+type v2Prefetch struct {
+	mu       sync.Mutex
+	packages map[string]PackageData
+}
+
+// PrefetchV2 looks up many exact package versions of a V2 feed with few $filter queries. Later Metadata
+// calls for these packages are answered from the results. Packages missing from the results are still
+// looked up individually, since servers differ in how they match versions. Other protocols and inexact
+// versions are ignored.
+func (c *Client) PrefetchV2(ctx context.Context, source PackageSource, packages []PackageIdentity) error {
+	if source.IsLocal() {
+		return nil
+	}
+
+	index, err := c.ServiceIndex(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	if index.Protocol != ProtocolV2 {
+		return nil
+	}
+
+	var exact []PackageIdentity
+	for _, p := range packages {
+		if IsExactVersion(p.Version) {
+			exact = append(exact, p)
+		}
+	}
+
+	batches, err := v2Batches(source.Path, exact)
+	if err != nil {
+		return err
+	}
+
+	prefetch := c.v2Prefetch(source)
+
+	for _, batch := range batches {
+		response, err := c.queryApiV2Pages(ctx, source, batch.url)
+		if err != nil {
+			return err
+		}
+
+		prefetch.mu.Lock()
+		for _, d := range response.Data {
+			prefetch.packages[PackageIdentity{Id: d.Id, Version: d.Version}.key()] = d
+		}
+		prefetch.mu.Unlock()
+	}
+
+	return nil
+}
+
+// prefetchedV2 returns the prefetched package version, if any.
+func (c *Client) prefetchedV2(source PackageSource, id string, version string) *PackageData {
+	c.mu.Lock()
+	prefetch, ok := c.prefetched[source.Path]
+	c.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	key := PackageIdentity{Id: id, Version: version}.key()
+
+	prefetch.mu.Lock()
+	defer prefetch.mu.Unlock()
+
+	if d, ok := prefetch.packages[key]; ok {
+		return &d
+	}
+	return nil
+}
+
+func (c *Client) v2Prefetch(source PackageSource) *v2Prefetch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefetch, ok := c.prefetched[source.Path]
+	if !ok {
+		prefetch = &v2Prefetch{packages: make(map[string]PackageData)}
+		c.prefetched[source.Path] = prefetch
+	}
+	return prefetch
+}
+
+type v2Batch struct {
+	url      string
+	packages []PackageIdentity
+}
+
+// v2Batches builds Packages()?$filter=... query URLs, adding packages to a batch while the URL stays
+// below MaxV2UrlLength. Ids are matched case-insensitively, like NuGet does. Both the version as written
+// and the normalized version are matched, because older servers only filter on the original version.
+func v2Batches(sourceUrl string, packages []PackageIdentity) ([]v2Batch, error) {
+	u, err := url.Parse(sourceUrl)
+	if err != nil {
+		return nil, err
+	}
+	setODataPath(u, "Packages()")
+
+	build := func(clauses []string) string {
+		q := u.Query()
+		q.Set("$filter", strings.Join(clauses, " or "))
+		q.Set("semVerLevel", semVerLevel)
+		b := *u
+		// spaces are sent as %20, not all V2 servers decode + in the query string
+		b.RawQuery = strings.Replace(q.Encode(), "+", "%20", -1)
+		return b.String()
+	}
+
+	var batches []v2Batch
+	var clauses []string
+	var current []PackageIdentity
+
+	for _, p := range packages {
+		versions := []string{fmt.Sprintf("Version eq '%s'", escapeODataString(p.Version))}
+		if normalized := NormalizeVersion(p.Version); normalized != p.Version {
+			versions = append(versions, fmt.Sprintf("Version eq '%s'", escapeODataString(normalized)))
+		}
+
+		clause := fmt.Sprintf("(tolower(Id) eq '%s' and (%s))", escapeODataString(strings.ToLower(p.Id)),
+			strings.Join(versions, " or "))
+
+		if len(clauses) > 0 && len(build(append(clauses, clause))) > MaxV2UrlLength {
+			batches = append(batches, v2Batch{url: build(clauses), packages: current})
+			clauses, current = nil, nil
+		}
+
+		clauses = append(clauses, clause)
+		current = append(current, p)
+	}
+
+	if len(clauses) > 0 {
+		batches = append(batches, v2Batch{url: build(clauses), packages: current})
+	}
+
+	return batches, nil
+}
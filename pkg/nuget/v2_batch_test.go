@@ -0,0 +1,168 @@
+package nuget
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func v2Entry(id string, version string) string {
+	return fmt.Sprintf(`<entry><title>%[1]s</title><m:properties><d:Id>%[1]s</d:Id><d:Version>%[2]s</d:Version>`+
+		`<d:NormalizedVersion>%[2]s</d:NormalizedVersion></m:properties></entry>`, id, version)
+}
+
+// newV2Feed starts a V2 feed whose Packages() query returns the batch entries and whose
+// Packages(Id,Version) lookup returns the packages. Request URLs are recorded.
+func newV2Feed(t *testing.T, batch []string, packages map[string]string) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.RequestURI())
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/atom+xml")
+		const feed = `<feed xmlns="http://www.w3.org/2005/Atom" ` +
+			`xmlns:d="http://schemas.microsoft.com/ado/2007/08/dataservices" ` +
+			`xmlns:m="http://schemas.microsoft.com/ado/2007/08/dataservices/metadata">%s</feed>`
+
+		switch {
+		case r.URL.Path == "/nuget":
+			fmt.Fprintf(w, feed, "")
+		case r.URL.Path == "/nuget/Packages()":
+			fmt.Fprintf(w, feed, strings.Join(batch, ""))
+		case strings.HasPrefix(r.URL.Path, "/nuget/Packages("):
+			entry, ok := packages[strings.TrimPrefix(r.URL.Path, "/nuget/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(entry))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestV2Batches(t *testing.T) {
+	packages := []PackageIdentity{
+		{Id: "Newtonsoft.Json", Version: "13.0.1"},
+		{Id: "O'Brien.Utils", Version: "1.0"},
+		{Id: "Serilog", Version: "2.12.0"},
+	}
+
+	batches, err := v2Batches("https://feed.example/nuget", packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("v2Batches() = %d batches, want 1", len(batches))
+	}
+
+	u, err := url.Parse(batches[0].url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/nuget/Packages()" {
+		t.Errorf("path = %q, want /nuget/Packages()", u.Path)
+	}
+	if v := u.Query().Get("semVerLevel"); v != "2.0.0" {
+		t.Errorf("semVerLevel = %q, want 2.0.0", v)
+	}
+
+	want := "(tolower(Id) eq 'newtonsoft.json' and (Version eq '13.0.1')) or " +
+		"(tolower(Id) eq 'o''brien.utils' and (Version eq '1.0' or Version eq '1.0.0')) or " +
+		"(tolower(Id) eq 'serilog' and (Version eq '2.12.0'))"
+	if filter := u.Query().Get("$filter"); filter != want {
+		t.Errorf("$filter = %q, want %q", filter, want)
+	}
+
+	// a short limit puts every package into its own batch
+	defer func(length int) { MaxV2UrlLength = length }(MaxV2UrlLength)
+	MaxV2UrlLength = 1
+
+	batches, err = v2Batches("https://feed.example/nuget", packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != len(packages) {
+		t.Fatalf("v2Batches() = %d batches, want %d", len(batches), len(packages))
+	}
+	for i, batch := range batches {
+		if len(batch.packages) != 1 || batch.packages[0] != packages[i] {
+			t.Errorf("batch %d packages = %v, want %v", i, batch.packages, packages[i])
+		}
+	}
+}
+
+func TestClientPrefetchV2(t *testing.T) {
+	server, requests := newV2Feed(t,
+		[]string{v2Entry("Serilog", "2.12.0")},
+		map[string]string{
+			// the batch query misses SemVer 2.0.0 versions on some servers
+			"Packages(Id='Newtonsoft.Json',Version='13.0.1-beta.1+build')": v2Entry("Newtonsoft.Json", "13.0.1-beta.1"),
+		})
+
+	source := PackageSource{SourceName: "feed", Path: server.URL + "/nuget"}
+	client := NewNugetClient().WithRetryPolicy(RetryPolicy{})
+	ctx := context.Background()
+
+	err := client.PrefetchV2(ctx, source, []PackageIdentity{
+		{Id: "serilog", Version: "2.12.0"},
+		{Id: "Newtonsoft.Json", Version: "13.0.1-beta.1+build"},
+		{Id: "Missing", Version: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("PrefetchV2() error = %v", err)
+	}
+	prefetched := len(requests())
+
+	d, err := client.Metadata(ctx, source, "serilog", "2.12.0")
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if d.Id != "Serilog" {
+		t.Errorf("Id = %q, want Serilog", d.Id)
+	}
+	if n := len(requests()); n != prefetched {
+		t.Errorf("prefetched package sent %d requests, want none", n-prefetched)
+	}
+
+	// packages missing from the batch results are looked up individually
+	d, err = client.Metadata(ctx, source, "Newtonsoft.Json", "13.0.1-beta.1+build")
+	if err != nil {
+		t.Fatalf("Metadata() error = %v", err)
+	}
+	if d.Version != "13.0.1-beta.1" {
+		t.Errorf("Version = %q, want 13.0.1-beta.1", d.Version)
+	}
+
+	if _, err := client.Metadata(ctx, source, "Missing", "1.0.0"); err != ErrPackageNotFound {
+		t.Errorf("Metadata() error = %v, want %v", err, ErrPackageNotFound)
+	}
+
+	var lookups int
+	for _, r := range requests()[prefetched:] {
+		if strings.HasPrefix(r, "/nuget/Packages(Id=") {
+			lookups++
+		}
+	}
+	if lookups < 2 {
+		t.Errorf("Packages(Id,Version) lookups = %d, want at least 2", lookups)
+	}
+}
@@ -0,0 +1,130 @@
+package nuget
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NormalizeVersion returns the normalized form NuGet uses for folder names and comparisons:
+// at least three numeric parts, a zero revision is dropped, leading zeros and build metadata are removed.
+// Values which are not plain versions, such as ranges or floating versions, are returned unchanged.
+func NormalizeVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if !IsExactVersion(version) {
+		return version
+	}
+
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+
+	var release string
+	if i := strings.Index(version, "-"); i >= 0 {
+		version, release = version[:i], version[i:]
+	}
+
+	parts := strings.Split(version, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	for i, p := range parts {
+		if n, err := strconv.ParseUint(p, 10, 64); err == nil {
+			parts[i] = strconv.FormatUint(n, 10)
+		}
+	}
+
+	if len(parts) == 4 && parts[3] == "0" {
+		parts = parts[:3]
+	}
+
+	return strings.Join(parts, ".") + release
+}
+
+// IsExactVersion reports whether the version refers to a single version rather than a range or floating version.
+func IsExactVersion(version string) bool {
+	return version != "" && !strings.ContainsAny(version, "[](),* ")
+}
+
+// CompareVersions compares two versions using SemVer 2.0 precedence and returns -1, 0 or 1.
+// Build metadata is ignored and release labels are compared case-insensitively.
+func CompareVersions(a string, b string) int {
+	an, ar := splitVersion(NormalizeVersion(a))
+	bn, br := splitVersion(NormalizeVersion(b))
+
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y uint64
+		if i < len(an) {
+			x = an[i]
+		}
+		if i < len(bn) {
+			y = bn[i]
+		}
+		if x != y {
+			return compareUint(x, y)
+		}
+	}
+
+	// a version without release labels has a higher precedence
+	switch {
+	case len(ar) == 0 && len(br) == 0:
+		return 0
+	case len(ar) == 0:
+		return 1
+	case len(br) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(ar) && i < len(br); i++ {
+		x, xErr := strconv.ParseUint(ar[i], 10, 64)
+		y, yErr := strconv.ParseUint(br[i], 10, 64)
+
+		switch {
+		case xErr == nil && yErr == nil:
+			if x != y {
+				return compareUint(x, y)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(strings.ToLower(ar[i]), strings.ToLower(br[i])); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareUint(uint64(len(ar)), uint64(len(br)))
+}
+
+// SameVersion reports whether both values refer to the same normalized version.
+func SameVersion(a string, b string) bool {
+	return strings.EqualFold(NormalizeVersion(a), NormalizeVersion(b))
+}
+
+// splitVersion splits a normalized version into its numeric parts and release labels.
+func splitVersion(version string) ([]uint64, []string) {
+	var labels []string
+	if i := strings.Index(version, "-"); i >= 0 {
+		version, labels = version[:i], strings.Split(version[i+1:], ".")
+	}
+
+	var numbers []uint64
+	for _, p := range strings.Split(version, ".") {
+		n, _ := strconv.ParseUint(p, 10, 64)
+		numbers = append(numbers, n)
+	}
+
+	return numbers, labels
+}
+
+func compareUint(x uint64, y uint64) int {
+	if x < y {
+		return -1
+	}
+	if x > y {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package sln
+
+import "fmt"
+
+// SupportedFormatVersions lists solution file format versions the parser fully understands.
+var SupportedFormatVersions = []string{
+	"11.00",
+	"12.00",
+}
+
+// CheckFormat returns an error if the solution file format is missing or not fully supported.
+func (s Solution) CheckFormat() error {
+	if s.FormatVersion == "" {
+		return fmt.Errorf("solution file header not found")
+	}
+
+	for _, v := range SupportedFormatVersions {
+		if s.FormatVersion == v {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("solution file format version %s is not fully supported", s.FormatVersion)
+}
@@ -1,6 +1,7 @@
 package sln
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -21,21 +22,31 @@ func NewSolutionParser(r io.Reader) *SolutionParser {
 	return &SolutionParser{s: NewScanner(r)}
 }
 
+// ParseString parses a quoted string or a single unquoted token.
+// Doubled quotes inside a quoted string are unescaped to a single quote.
 func (sp *SolutionParser) ParseString() (string, error) {
 	tok, lit := sp.scanIgnoreWhitespace()
 	if tok != QUOTE {
 		return lit, nil
 	} else {
-		var s string
+		var s strings.Builder
 		for {
 			tok, lit := sp.scan()
+			if tok == EOF {
+				return s.String(), errors.New("unterminated string")
+			}
 			if tok != QUOTE {
-				s = s + lit
-			} else {
-				break
+				s.WriteString(lit)
+				continue
+			}
+			if next, _ := sp.scan(); next == QUOTE {
+				s.WriteString(lit)
+				continue
 			}
+			sp.unscan()
+			break
 		}
-		return s, nil
+		return s.String(), nil
 	}
 }
 
@@ -73,17 +84,61 @@ func (sp *SolutionParser) expect(expected ...Token) (bool, error) {
 	return true, nil
 }
 
-// Parse parses a SQL SELECT statement.
+// ParseHeader parses the remainder of the "Microsoft Visual Studio Solution File" line
+// and returns the format version.
+func (sp *SolutionParser) ParseHeader() (string, error) {
+	line := sp.scanLine()
+
+	i := strings.LastIndex(line, "Format Version")
+	if i < 0 {
+		return "", fmt.Errorf("unexpected solution header %q", line)
+	}
+
+	return strings.TrimSpace(line[i+len("Format Version"):]), nil
+}
+
+// ParseProperty parses the remainder of a "Name = Value" line and returns the value.
+func (sp *SolutionParser) ParseProperty() (string, error) {
+	if ok, err := sp.expect(EQUAL); !ok {
+		return "", err
+	}
+	return strings.TrimSpace(sp.scanLine()), nil
+}
+
+// Parse parses a solution file.
 func (sp *SolutionParser) Parse() (Solution, error) {
 	var sln Solution
 	for {
-		tok, _ := sp.scanIgnoreWhitespace()
+		tok, lit := sp.scanIgnoreWhitespace()
 		switch tok {
 		case EOF:
 			sp.unscan()
 		case PROJECT:
+			// "Project" without an opening parenthesis, like in a section entry, does not start a project
+			next, _ := sp.scanIgnoreWhitespace()
+			sp.unscan()
+			if next != OPEN_PAREN {
+				break
+			}
+
 			proj, _ := sp.ParseProject()
 			sln.Projects = append(sln.Projects, proj)
+		case HASH:
+			comment := strings.TrimSpace(sp.scanLine())
+			if sln.Product == "" && len(sln.Projects) == 0 {
+				sln.Product = comment
+			}
+		case IDENT:
+			switch lit {
+			case "Microsoft":
+				if sln.FormatVersion == "" {
+					sln.FormatVersion, _ = sp.ParseHeader()
+				}
+			case "VisualStudioVersion":
+				sln.VisualStudioVersion, _ = sp.ParseProperty()
+			case "MinimumVisualStudioVersion":
+				sln.MinimumVisualStudioVersion, _ = sp.ParseProperty()
+			}
 		}
 		if tok == EOF {
 			break
@@ -119,5 +174,15 @@ func (sp *SolutionParser) scanIgnoreWhitespace() (tok Token, lit string) {
 	return
 }
 
+// scanLine returns the rest of the current line, including a buffered token if any.
+func (sp *SolutionParser) scanLine() string {
+	var prefix string
+	if sp.buf.n != 0 {
+		sp.buf.n = 0
+		prefix = sp.buf.lit
+	}
+	return prefix + sp.s.ScanLine()
+}
+
 // unscan pushes the previously read token back onto the buffer.
 func (sp *SolutionParser) unscan() { sp.buf.n = 1 }
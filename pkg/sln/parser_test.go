@@ -0,0 +1,281 @@
+package sln
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSolutionFiles(t *testing.T) {
+	sep := string(filepath.Separator)
+
+	tests := []struct {
+		file          string
+		formatVersion string
+		product       string
+		vsVersion     string
+		projects      []Project
+	}{
+		{
+			file:          "bom_crlf.sln",
+			formatVersion: "12.00",
+			product:       "Visual Studio Version 16",
+			vsVersion:     "16.0.30114.105",
+			projects: []Project{
+				{
+					ID:          "{5B1A3E63-7C2D-4F1B-9D0A-3C8E5F6A7B21}",
+					Name:        "WebApi",
+					ProjectFile: "src" + sep + "WebApi" + sep + "WebApi.csproj",
+					TypeGUID:    "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}",
+				},
+				{
+					ID:          "{8C4D2A11-0E5F-4B3C-A6D7-1F2E3D4C5B6A}",
+					Name:        "tests",
+					ProjectFile: "tests",
+					TypeGUID:    "{2150E333-8FDC-42A3-9474-1A3956D46DE8}",
+				},
+				{
+					ID:          "{E2F1D0C9-B8A7-4965-8374-6352F1E0D9C8}",
+					Name:        "WebApi.Tests",
+					ProjectFile: "tests" + sep + "WebApi.Tests" + sep + "WebApi.Tests.csproj",
+					TypeGUID:    "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}",
+				},
+			},
+		},
+		{
+			file:          "unicode.sln",
+			formatVersion: "12.00",
+			product:       "Visual Studio Version 17",
+			vsVersion:     "17.5.33414.496",
+			projects: []Project{
+				{
+					ID:          "{0A1B2C3D-4E5F-4061-8273-94A5B6C7D8E9}",
+					Name:        "Überprüfung",
+					ProjectFile: "Überprüfung" + sep + "Überprüfung.csproj",
+					TypeGUID:    "{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}",
+				},
+				{
+					ID:          "{1B2C3D4E-5F60-4172-8394-A5B6C7D8E9F0}",
+					Name:        "日本語.Library",
+					ProjectFile: "lib" + sep + "日本語.Library.fsproj",
+					TypeGUID:    "{F2A71F9B-5D33-465A-A702-920D77279786}",
+				},
+			},
+		},
+		{
+			file:          "vs2022.sln",
+			formatVersion: "12.00",
+			product:       "Visual Studio Version 17",
+			vsVersion:     "17.5.33516.290",
+			projects: []Project{
+				{
+					ID:          "{6F1E2B7A-3C4D-4E5F-8A9B-0C1D2E3F4A5B}",
+					Name:        "Contoso.Api",
+					ProjectFile: "src" + sep + "Contoso.Api" + sep + "Contoso.Api.csproj",
+					TypeGUID:    "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}",
+				},
+				{
+					ID:          "{7A2F3C8B-4D5E-4F60-9BAC-1D2E3F4A5B6C}",
+					Name:        "Contoso.Core",
+					ProjectFile: "src" + sep + "Contoso.Core" + sep + "Contoso.Core.csproj",
+					TypeGUID:    "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}",
+				},
+				{
+					ID:          "{8B3A4D9C-5E6F-4071-ACBD-2E3F4A5B6C7D}",
+					Name:        "Solution Items",
+					ProjectFile: "Solution Items",
+					TypeGUID:    "{2150E333-8FDC-42A3-9474-1A3956D46DE8}",
+				},
+				{
+					ID:          "{9C4B5EAD-6F70-4182-BDCE-3F4A5B6C7D8E}",
+					Name:        "tests",
+					ProjectFile: "tests",
+					TypeGUID:    "{2150E333-8FDC-42A3-9474-1A3956D46DE8}",
+				},
+				{
+					ID:          "{AD5C6FBE-7081-4293-CEDF-4A5B6C7D8E9F}",
+					Name:        "Contoso.Api.Tests",
+					ProjectFile: "tests" + sep + "Contoso.Api.Tests" + sep + "Contoso.Api.Tests.csproj",
+					TypeGUID:    "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}",
+				},
+			},
+		},
+		{
+			file:          "quotes.sln",
+			formatVersion: "11.00",
+			product:       "Visual Studio 2010",
+			projects: []Project{
+				{
+					ID:          "{2C3D4E5F-6071-4283-94A5-B6C7D8E9F0A1}",
+					Name:        `Legacy "Quoted" App`,
+					ProjectFile: "Legacy" + sep + "Legacy.vbproj",
+					TypeGUID:    "{F184B08F-C81C-45F6-A57F-5ABD9991F28F}",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			sln, err := NewSolutionParser(f).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if sln.FormatVersion != tt.formatVersion {
+				t.Errorf("FormatVersion = %q, want %q", sln.FormatVersion, tt.formatVersion)
+			}
+			if sln.Product != tt.product {
+				t.Errorf("Product = %q, want %q", sln.Product, tt.product)
+			}
+			if sln.VisualStudioVersion != tt.vsVersion {
+				t.Errorf("VisualStudioVersion = %q, want %q", sln.VisualStudioVersion, tt.vsVersion)
+			}
+			if !reflect.DeepEqual(sln.Projects, tt.projects) {
+				t.Errorf("Projects = %#v, want %#v", sln.Projects, tt.projects)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: `"MyApp"`, want: "MyApp"},
+		{input: `"My ""Quoted"" App"`, want: `My "Quoted" App`},
+		{input: `""""`, want: `"`},
+		{input: `""`, want: ""},
+		{input: `Unquoted`, want: "Unquoted"},
+		{input: `"Ünïcödé 名前"`, want: "Ünïcödé 名前"},
+		{input: `"unterminated`, want: "unterminated", wantErr: true},
+		{input: `"unterminated ""quote`, want: `unterminated "quote`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			sp := NewSolutionParser(strings.NewReader(tt.input))
+
+			got, err := sp.ParseString()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseString() = %q, want %q", got, tt.want)
+			}
+
+			// an unterminated string consumes the input up to EOF
+			if tt.wantErr {
+				if tok, lit := sp.scan(); tok != EOF {
+					t.Errorf("next token = %v %q, want EOF", tok, lit)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnterminatedProject(t *testing.T) {
+	input := "Microsoft Visual Studio Solution File, Format Version 12.00\r\n" +
+		`Project("{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}") = "MyApp", "MyApp\MyApp.csproj`
+
+	sln, err := NewSolutionParser(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(sln.Projects) != 1 {
+		t.Fatalf("Projects = %d, want 1", len(sln.Projects))
+	}
+
+	want := "MyApp" + string(filepath.Separator) + "MyApp.csproj"
+	if sln.Projects[0].ProjectFile != want {
+		t.Errorf("ProjectFile = %q, want %q", sln.Projects[0].ProjectFile, want)
+	}
+}
+
+func TestParseProjectKeywordInSection(t *testing.T) {
+	input := "Microsoft Visual Studio Solution File, Format Version 12.00\r\n" +
+		`Project("{2150E333-8FDC-42A3-9474-1A3956D46DE8}") = "Solution Items", "Solution Items", "{8B3A4D9C-5E6F-4071-ACBD-2E3F4A5B6C7D}"` + "\r\n" +
+		"\tProjectSection(SolutionItems) = preProject\r\n" +
+		"\t\tProject.props = Project.props\r\n" +
+		"\tEndProjectSection\r\n" +
+		"EndProject\r\n"
+
+	sln, err := NewSolutionParser(strings.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(sln.Projects) != 1 || sln.Projects[0].Name != "Solution Items" {
+		t.Errorf("Projects = %#v, want only the solution folder", sln.Projects)
+	}
+}
+
+func FuzzSolutionParser(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.sln"))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sln, err := NewSolutionParser(bytes.NewReader(data)).Parse()
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+
+		if n := projectTokens(data); len(sln.Projects) > n {
+			t.Errorf("Parse() = %d projects, but the input has %d Project( tokens", len(sln.Projects), n)
+		}
+
+		for _, p := range sln.Projects {
+			if filepath.Separator != '\\' && strings.Contains(p.ProjectFile, `\`) {
+				t.Errorf("ProjectFile %q contains a backslash", p.ProjectFile)
+			}
+		}
+
+		if sln.FormatVersion != "" && !bytes.Contains(data, []byte("Format Version")) {
+			t.Errorf("FormatVersion = %q without a solution header", sln.FormatVersion)
+		}
+	})
+}
+
+// projectTokens counts the "Project" keywords which are followed by an opening parenthesis.
+func projectTokens(data []byte) int {
+	s := NewScanner(bytes.NewReader(data))
+
+	var n int
+	var project bool
+	for {
+		tok, _ := s.Scan()
+		switch tok {
+		case EOF:
+			return n
+		case WS:
+			continue
+		case OPEN_PAREN:
+			if project {
+				n++
+			}
+		}
+		project = tok == PROJECT
+	}
+}
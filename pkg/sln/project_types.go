@@ -0,0 +1,104 @@
+package sln
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ProjectKind groups project types by how their references can be scanned.
+type ProjectKind int
+
+const (
+	// KindUnknown - project type GUID is not registered
+	KindUnknown ProjectKind = iota
+	// KindManaged - MSBuild project which may contain PackageReference items
+	KindManaged
+	// KindNative - native C++ project
+	KindNative
+	// KindSolutionFolder - virtual folder without a project file
+	KindSolutionFolder
+	// KindWebSite - web site project without a project file
+	KindWebSite
+	// KindDatabase - database project
+	KindDatabase
+	// KindShared - shared project included into other projects
+	KindShared
+	// KindOther - known project type which is not scanned
+	KindOther
+)
+
+type ProjectType struct {
+	GUID string
+	Name string
+	Kind ProjectKind
+}
+
+// ProjectTypes contains well-known project type GUIDs.
+var ProjectTypes = []ProjectType{
+	{GUID: "{FAE04EC0-301F-11D3-BF4B-00C04F79EFBC}", Name: "C#", Kind: KindManaged},
+	{GUID: "{9A19103F-16F7-4668-BE54-9A1E7A4F7556}", Name: "C# (SDK-style)", Kind: KindManaged},
+	{GUID: "{F2A71F9B-5D33-465A-A702-920D77279786}", Name: "F#", Kind: KindManaged},
+	{GUID: "{6EC3EE1D-3C4E-46DD-8F32-0CC8E7565705}", Name: "F# (SDK-style)", Kind: KindManaged},
+	{GUID: "{F184B08F-C81C-45F6-A57F-5ABD9991F28F}", Name: "VB.NET", Kind: KindManaged},
+	{GUID: "{778DAE3C-4631-46EA-AA77-85C1314464D9}", Name: "VB.NET (SDK-style)", Kind: KindManaged},
+	{GUID: "{349C5851-65DF-11DA-9384-00065B846F21}", Name: "Web Application", Kind: KindManaged},
+	{GUID: "{8BC9CEB8-8B4A-11D0-8D11-00A0C91BC942}", Name: "C++", Kind: KindNative},
+	{GUID: "{2150E333-8FDC-42A3-9474-1A3956D46DE8}", Name: "Solution Folder", Kind: KindSolutionFolder},
+	{GUID: "{E24C65DC-7377-472B-9ABA-BC803B73C61A}", Name: "Web Site", Kind: KindWebSite},
+	{GUID: "{00D1A9C2-B5F0-4AF3-8072-F6C62B433612}", Name: "SQL Server Database", Kind: KindDatabase},
+	{GUID: "{C8D11400-126E-41CD-887F-60BD40844F9E}", Name: "Database", Kind: KindDatabase},
+	{GUID: "{A9ACE9BB-CECE-4E62-9AA4-C7E7C5BD2124}", Name: "Database (Other)", Kind: KindDatabase},
+	{GUID: "{D954291E-2A0B-460D-934E-DC6B0785DB48}", Name: "Shared Project", Kind: KindShared},
+	{GUID: "{54435603-DBB4-11D2-8724-00A0C9A8B90C}", Name: "Setup Project", Kind: KindOther},
+	{GUID: "{930C7802-8A8C-48F9-8165-68863BCCD9DD}", Name: "WiX Installer", Kind: KindOther},
+	{GUID: "{888888A0-9F3D-457C-B088-3A5042F75D52}", Name: "Python", Kind: KindOther},
+	{GUID: "{9092AA53-FB77-4645-B42D-1CCCA6BD08BD}", Name: "Node.js", Kind: KindOther},
+}
+
+// managedExtensions contains project file extensions which are parsed as MSBuild projects.
+var managedExtensions = []string{".csproj", ".fsproj", ".vbproj"}
+
+// LookupProjectType returns the registered project type for the given GUID.
+func LookupProjectType(guid string) (ProjectType, bool) {
+	guid = strings.ToUpper(strings.TrimSpace(guid))
+	if !strings.HasPrefix(guid, "{") {
+		guid = "{" + guid + "}"
+	}
+
+	for _, pt := range ProjectTypes {
+		if pt.GUID == guid {
+			return pt, true
+		}
+	}
+
+	return ProjectType{GUID: guid, Name: "Unknown", Kind: KindUnknown}, false
+}
+
+// Type returns the project type based on the project type GUID.
+func (p Project) Type() ProjectType {
+	pt, _ := LookupProjectType(p.TypeGUID)
+	return pt
+}
+
+// IsManaged reports whether the project can contain PackageReference items.
+// Projects with an unknown type GUID fall back to the project file extension.
+func (p Project) IsManaged() bool {
+	switch p.Type().Kind {
+	case KindManaged:
+		return true
+	case KindUnknown:
+		return IsManagedProjectFile(p.ProjectFile)
+	}
+	return false
+}
+
+// IsManagedProjectFile reports whether the file extension belongs to an MSBuild project with package references.
+func IsManagedProjectFile(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, e := range managedExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"io"
 	"strings"
+	"unicode"
 )
 
 type Scanner struct {
@@ -12,8 +13,13 @@ type Scanner struct {
 }
 
 // NewScanner returns a new instance of Scanner.
+// A leading UTF-8 byte order mark is skipped.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	s := &Scanner{r: bufio.NewReader(r)}
+	if ch := s.read(); ch != bom {
+		s.unread()
+	}
+	return s
 }
 
 // Scan returns the next token and literal value.
@@ -48,11 +54,28 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 		return EQUAL, string(ch)
 	case '"':
 		return QUOTE, string(ch)
+	case '#':
+		return HASH, string(ch)
 	}
 
 	return Unknown, string(ch)
 }
 
+// ScanLine consumes the rest of the current line and returns it without the line break.
+func (s *Scanner) ScanLine() string {
+	var buf bytes.Buffer
+
+	for {
+		if ch := s.read(); ch == eof || ch == '\n' {
+			break
+		} else {
+			buf.WriteRune(ch)
+		}
+	}
+
+	return strings.TrimRight(buf.String(), "\r")
+}
+
 // scanWhitespace consumes the current rune and all contiguous whitespace.
 func (s *Scanner) scanWhitespace() (tok Token, lit string) {
 	// create a buffer and read the current character into it.
@@ -86,7 +109,7 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 	for {
 		if ch := s.read(); ch == eof {
 			break
-		} else if !isLetter(ch) && !isDigit(ch) && ch != '_' {
+		} else if !isLetter(ch) && !isDigit(ch) && ch != '_' && !unicode.Is(unicode.Mn, ch) {
 			s.unread()
 			break
 		} else {
@@ -107,7 +130,7 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 }
 
 // read the next rune from the buffered reader.
-// Returns the rune(0) if an error occurs (or io.EOF is returned).
+// Returns eof if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
 	if err != nil {
@@ -119,14 +142,18 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() { _ = s.r.UnreadRune() }
 
-// isWhitespace returns true if the rune is a space, tab, or newline.
-func isWhitespace(ch rune) bool { return ch == ' ' || ch == '\t' || ch == '\n' }
+// isWhitespace returns true if the rune is a space, tab, carriage return, newline or other Unicode space.
+func isWhitespace(ch rune) bool { return ch != eof && unicode.IsSpace(ch) }
 
-// isLetter returns true if the rune is a letter.
-func isLetter(ch rune) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') }
+// isLetter returns true if the rune is a Unicode letter.
+func isLetter(ch rune) bool { return ch != eof && unicode.IsLetter(ch) }
 
-// isDigit returns true if the rune is a digit.
-func isDigit(ch rune) bool { return ch >= '0' && ch <= '9' }
+// isDigit returns true if the rune is a Unicode digit.
+func isDigit(ch rune) bool { return ch != eof && unicode.IsDigit(ch) }
 
 // eof represents a marker rune for the end of the reader.
-var eof = rune(0)
+// It is outside the Unicode range, so NUL characters in the input are not mistaken for it.
+var eof = rune(-1)
+
+// bom represents the UTF-8 byte order mark.
+var bom = '\uFEFF'
@@ -1,7 +1,11 @@
 package sln
 
 type Solution struct {
-	Projects []Project
+	FormatVersion              string
+	Product                    string
+	VisualStudioVersion        string
+	MinimumVisualStudioVersion string
+	Projects                   []Project
 }
 
 type Project struct {
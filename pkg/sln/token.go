@@ -26,6 +26,8 @@ const (
 	QUOTE
 	// EQUAL =
 	EQUAL
+	// HASH #
+	HASH
 
 	// PROJECT - project begin
 	PROJECT